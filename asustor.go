@@ -11,47 +11,92 @@ package display
 
 import (
 	"bytes"
+	"context"
 	"errors"
-	"github.com/chmorgan/go-serial2/serial"
+	"github.com/artvel/display/asustorproto"
 	"io"
+	"log"
 	"sync"
 	"time"
 )
 
+// asustorTTYs are the ports of the panel on the different ASUSTOR
+// generations, NewAsustorLCD tries them if the given port fails.
+var asustorTTYs = []string{"/dev/ttyS1", "/dev/ttyS0", "/dev/ttyS2"}
+
+// The raw button ids the ASUSTOR driver passes to Listen, as observed
+// on the AS6404T.
+const (
+	AsustorRawUp    = 1
+	AsustorRawDown  = 2
+	AsustorRawBack  = 3
+	AsustorRawEnter = 4
+)
+
+var asustorButtons = map[int]Button{
+	AsustorRawUp:    ButtonUp,
+	AsustorRawDown:  ButtonDown,
+	AsustorRawBack:  ButtonBack,
+	AsustorRawEnter: ButtonEnter,
+}
+
 // we hide the struct and its fields
 // to keep the usage as simple as possible
 // through the LCD interface
 type asustor struct {
-	con           io.ReadWriteCloser
-	readC         chan []byte
-	btnC          chan []byte
-	tty           string
-	open          bool
-	keepListening bool
+	con       *conn
+	connect   connector
+	replies   *matcher
+	btnC      chan ButtonEvent
+	drop      DropPolicy
+	counters  counters
+	tracer    *tracer
+	listeners *listeners
+	repeat    *repeater
+	fb        *Framebuffer
+	state     *lifecycle
+	onWrite   func(line Line, text string)
+	offline   *offlineQueue
+	// writes while disabled
+	retained retained
+	errC     chan error
+	tty      string
+	open     openFlag
+	// frozen displays ignore writes, see ShowShutdown
+	frozen bool
 
 	m sync.Mutex
 
-	retry byte
+	retry   int
+	retries int
+	// readTimeout for the acknowledgement of a frame
+	readTimeout time.Duration
+	noAck       bool
+	width       int
+	lines       int
 
-	// to keep track of the 10ms
+	// to keep track of the delay
 	// we have to wait for to be flushed
-	lastFlush time.Time
+	pacer        pacer
+	clock        Clock
+	timeout      time.Duration
+	closeTimeout time.Duration
+	// the reader and the writer, Close waits for them. Every Open
+	// starts a new group, the former might still wait for a stuck port.
+	bg *sync.WaitGroup
+	// closed to stop the reader
+	stop chan struct{}
 
 	// keep the fields packed inside the struct
 	// to simplify the implementation of other
 	// displays on the package level
 	// and to prevent from reserving memory for
 	// unused package fields
-	cmdByte          byte
-	replyByte        byte
+	// the commands are encoded including the checksum
 	cmdDisplayStatus []byte
 	cmdDisplayOff    []byte
 	cmdClearDisplay  []byte
 	cmdDisplayOn     []byte
-	cmdBtn           []byte
-	cmdRdy           []byte
-
-	cmdOkayCheck []byte
 
 	replyRdy          []byte
 	replyMsgSentCheck []byte
@@ -64,31 +109,90 @@ Supports the display of the following devices:
 
 The constructor is responsible for init and probe.
 To simplify and unify the use of future displays.
+If tty fails, the ports of the other generations are tried,
+PortOf reports the one used.
 */
-func NewAsustorLCD(tty string) (LCD, error) {
+func NewAsustorLCD(tty string, opts ...Option) (LCD, error) {
 	if tty == "" {
 		tty = DefaultTTy
 	}
-	cmdByte := byte(240)
-	replyByte := byte(241)
-	m := &asustor{
-		tty:   tty,
-		readC: make(chan []byte, 100),
-		btnC:  make(chan []byte, 100),
+	o := newOptions(opts)
+	lcd, err := newAsustor(tty, o.serialConnector(asustorSerial(tty)), o)
+	if err == nil || err == ErrPortBusy || o.noPortScan {
+		return lcd, err
+	}
+	for _, alt := range asustorTTYs {
+		if alt == tty {
+			continue
+		}
+		if l, altErr := newAsustor(alt, o.serialConnector(asustorSerial(alt)), o); altErr == nil {
+			log.Printf("asustor: %s failed (%v), using %s", tty, err, alt)
+			return l, nil
+		}
+	}
+	return nil, err
+}
 
-		cmdByte:   cmdByte,
-		replyByte: replyByte,
+func asustorSerial(tty string) SerialConfig {
+	return SerialConfig{
+		PortName:        tty,
+		BaudRate:        115200,
+		DataBits:        8,
+		StopBits:        1,
+		MinimumReadSize: 1,
+	}
+}
 
-		cmdDisplayStatus: []byte{cmdByte, 1, 17, 1},
-		cmdDisplayOff:    []byte{cmdByte, 1, 17, 0},
-		cmdClearDisplay:  []byte{cmdByte, 1, 18, 1},
-		cmdDisplayOn:     []byte{cmdByte, 1, 34, 0},
-		cmdBtn:           []byte{cmdByte, 1, 128},
+// NewAsustorLCDFromConn uses an already opened connection,
+// like an in-memory pipe, a pty bridge or a TCP link, instead of
+// a serial port. Once closed, the display can't be reopened.
+func NewAsustorLCDFromConn(rwc io.ReadWriteCloser, opts ...Option) (LCD, error) {
+	return newAsustor("", connOnce(rwc), newOptions(opts))
+}
 
-		replyRdy:          []byte{replyByte, 1},
-		replyMsgSentCheck: []byte{replyByte, 1, 39, 0, 25},
+func newAsustor(tty string, connect connector, o *options) (LCD, error) {
+	readTimeout := durationOr(o.readTimeout, DefaultAsustorReadTimeout)
+	m := &asustor{
+		tty:          tty,
+		connect:      connect,
+		clock:        o.clock,
+		pacer:        pacer{clock: o.clock, delay: durationOr(o.writeDelay, DefaultDelayBetweenWrites)},
+		timeout:      durationOr(o.writeTimeout, DefaultWriteTimeout),
+		closeTimeout: durationOr(o.closeTimeout, DefaultCloseTimeout),
+		retries:      intOr(o.retries, defaultRetries),
+		readTimeout:  readTimeout,
+		replies:      newMatcher(o.clock, lateFactor*readTimeout),
+		noAck:        o.noAck,
+		width:        intOr(o.width, asustorproto.Width),
+		lines:        intOr(o.lines, asustorproto.Lines),
+		btnC:         make(chan ButtonEvent, o.queueLen()),
+		drop:         o.dropPolicy,
+		tracer:       newTracer(o.tracer, "asustor"),
+		listeners:    newListeners(asustorButtons),
+		repeat:       o.newRepeater(),
+		state:        newLifecycle(o.stateHook),
+		onWrite:      o.writeHook,
+		offline:      newOfflineQueue(o.offlineDepth),
+		retained:     retained{policy: o.disabledPolicy},
+		errC:         make(chan error, errBufferSize),
+
+		cmdDisplayStatus: asustorproto.Command(asustorproto.CmdDisplayStatus, 1).Encode(),
+		cmdDisplayOff:    asustorproto.Command(asustorproto.CmdDisplayStatus, 0).Encode(),
+		cmdClearDisplay:  asustorproto.Command(asustorproto.CmdClearDisplay, 1).Encode(),
+		cmdDisplayOn:     asustorproto.Command(asustorproto.CmdDisplayOn, 0).Encode(),
+
+		replyRdy: []byte{asustorproto.TypeReply, 1},
+		replyMsgSentCheck: asustorproto.Frame{
+			Type:    asustorproto.TypeReply,
+			Command: asustorproto.CmdWrite,
+			Data:    []byte{0},
+		}.Encode(),
 	}
 
+	m.fb = NewFramebuffer(m.lines)
+	if o.buttonLog != nil {
+		m.listeners.observe(o.buttonLog.Record)
+	}
 	// initial check if we can connect to a device
 	// that works our way
 	err := m.Open()
@@ -100,41 +204,67 @@ func NewAsustorLCD(tty string) (LCD, error) {
 	return m, err
 }
 
+// Port the display is connected to, empty for a connection.
+func (a *asustor) Port() string {
+	return a.tty
+}
+
+func (a *asustor) timeSource() Clock {
+	return a.clock
+}
+
 func (a *asustor) Open() error {
+	if err := a.openLocked(); err != nil {
+		return err
+	}
+	return a.offline.replay(a)
+}
+
+func (a *asustor) openLocked() (err error) {
 	a.m.Lock()
 	defer a.m.Unlock()
 
-	if a.open {
+	if a.open.get() {
 		return nil
 	}
-	var err error
+	end := a.tracer.start("display.open", "display.tty", a.tty)
+	defer func() { end(err) }()
+	a.state.set(StateOpening)
+	defer func() {
+		if err != nil {
+			a.state.set(StateClosed)
+		}
+	}()
+	defer recovered("open", &err)
+
 	if a.con != nil {
 		_ = a.con.Close()
 	}
-	a.con, err = serial.Open(serial.OpenOptions{
-		PortName:        a.tty,
-		BaudRate:        115200,
-		DataBits:        8,
-		StopBits:        1,
-		MinimumReadSize: 1,
-	})
+	a.bg = &sync.WaitGroup{}
+	a.con, err = dial(a.connect, a.bg)
 	if err != nil {
 		return err
 	}
 
-	a.open = true
-	go a.read()
-	return a.establish()
+	a.open.set(true)
+	a.stop = make(chan struct{})
+	a.bg.Add(1)
+	go a.read(a.con, a.stop, a.bg)
+	if err = a.establish(); err == nil {
+		a.counters.opened()
+		a.state.set(StateReady)
+	}
+	return err
 }
 
 func (a *asustor) establish() error {
-	err := a.flush(a.cmdDisplayStatus)
+	ok, err := a.request(a.cmdDisplayStatus, a.replies.expect(a.isReady))
 	if err != nil {
 		_ = a.con.Close()
 		_ = a.forceClose()
 		return err
 	}
-	if !a.responseEqual(true, a.replyRdy) {
+	if !ok {
 		_ = a.con.Close()
 		_ = a.forceClose()
 		return ErrDisplayNotWorking
@@ -149,137 +279,360 @@ func (a *asustor) Write(line Line, text string) error {
 	a.m.Lock()
 	defer a.m.Unlock()
 
-	return a.write(a.strToBytes(line, text))
+	return a.writeLine(line, text)
 }
 
-func (a *asustor) Enable(yes bool) error {
+// writeLines of a transaction with a single lock acquisition.
+func (a *asustor) writeLines(lines []lineText) error {
 	a.m.Lock()
 	defer a.m.Unlock()
 
-	if !a.open {
+	return commitLines(a.fb, a.writeLine, lines)
+}
+
+// writeLine must be called with the lock held.
+func (a *asustor) writeLine(line Line, text string) (err error) {
+	if a.frozen {
+		return nil
+	}
+	end := a.tracer.start("display.write", "display.line", int(line))
+	defer func() { end(err) }()
+
+	if line < 0 || int(line) >= a.lines {
+		return ErrUnsupportedLine
+	}
+	if !a.open.get() && a.offline.write(line, text) {
+		return nil
+	}
+	if a.open.get() && !a.fb.Enabled() {
+		if err = a.retained.write(line, text); err == nil {
+			a.fb.Set(line, fit(text, a.width))
+		}
+		return err
+	}
+	text = fit(text, a.width)
+	err = a.write(a.strToBytes(line, text))
+	a.state.result(err)
+	if err == nil {
+		a.fb.Set(line, text)
+		if a.onWrite != nil {
+			a.onWrite(line, text)
+		}
+	}
+	return err
+}
+
+func (a *asustor) Enable(yes bool) (err error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.frozen {
+		return nil
+	}
+	end := a.tracer.start("display.enable", "display.enabled", yes)
+	defer func() { end(err) }()
+
+	if !a.open.get() {
+		if a.offline.setEnabled(yes) {
+			return nil
+		}
 		return ErrClosed
 	}
 	if yes {
-		return a.flush(a.cmdDisplayOn)
+		err = a.flush(a.cmdDisplayOn)
 	} else {
-		return a.flush(a.cmdDisplayOff)
+		err = a.flush(a.cmdDisplayOff)
+	}
+	a.state.result(err)
+	if err != nil {
+		return err
+	}
+	a.fb.SetEnabled(yes)
+	if yes {
+		err = a.retained.replay(a.writeLine)
 	}
+	return err
 }
 
-func (a *asustor) Listen(l func(btn int, released bool) bool) {
-	if !a.open {
-		return
+// Clear all lines with a single command.
+func (a *asustor) Clear() (err error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.frozen {
+		return nil
 	}
-	a.keepListening = true
-	for a.open {
-		res := <-a.btnC
-		if !a.open {
-			return
-		}
-		if a.keepListening {
-			if !l(int(res[3]), true) {
-				a.keepListening = false
-				return
-			}
+	end := a.tracer.start("display.clear")
+	defer func() { end(err) }()
+
+	if !a.open.get() {
+		return ErrClosed
+	}
+	err = a.flush(a.cmdClearDisplay)
+	a.state.result(err)
+	if err == nil {
+		for i := range a.fb.Lines() {
+			a.fb.Set(Line(i), fit("", a.width))
 		}
 	}
+	return err
 }
 
-func (a *asustor) write(msg []byte) error {
-	if !a.open {
-		return ErrClosed
+// Status asks the display if it responds like the handshake does.
+// The status query turns the panel on, so while it is off only the
+// outcome of the last command is reported.
+func (a *asustor) Status() (DisplayStatus, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if !a.open.get() {
+		return DisplayStatus{}, ErrClosed
 	}
-	err := a.flush(msg)
+	if !a.fb.Content().Enabled {
+		return DisplayStatus{Responsive: a.state.get() == StateReady}, nil
+	}
+	ok, err := a.request(a.cmdDisplayStatus, a.replies.expect(a.isReady))
 	if err != nil {
-		return err
+		a.state.result(err)
+		return DisplayStatus{}, err
 	}
-	if !a.responseEqual(false, a.replyMsgSentCheck) {
-		if a.retry > 10 {
-			return ErrDisplayNotWorking
-		} else {
-			a.retry++
-			//log.Println("try", a.retry)
-			return a.write(msg)
-		}
+	if !ok {
+		a.state.result(ErrDisplayNotWorking)
 	} else {
-		a.retry = 0
+		a.state.result(nil)
 	}
-	return err
+	return DisplayStatus{On: true, Responsive: ok}, nil
 }
 
-func (a *asustor) responseEqual(hasPrefix bool, checks ...[]byte) bool {
-	ch := make(chan bool, 1)
-	go func() {
-		select {
-		case res := <-a.readC:
-			if !a.open {
-				ch <- false
-				return
-			}
-			for _, check := range checks {
-				if hasPrefix {
-					if bytes.HasPrefix(res, check) {
-						//log.Println("msg check OK!")
-						ch <- true
-						return
-					}
-				} else {
-					if bytes.Equal(res, check) {
-						//log.Println("msg check OK!")
-						ch <- true
-						return
-					}
-				}
-			}
-			ch <- false
-		case <-time.After(40 * time.Millisecond):
-			ch <- false
+// Flush blocks until the last write was processed by the display.
+func (a *asustor) Flush() error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if !a.open.get() {
+		return ErrClosed
+	}
+	a.pacer.settle()
+	return nil
+}
+
+func (a *asustor) Listen(l func(btn int, released bool) bool) {
+	a.ListenEvents(func(ev ButtonEvent) bool {
+		return l(ev.Raw, ev.Released)
+	})
+}
+
+// Width of a line in characters.
+func (a *asustor) Width() int {
+	return a.width
+}
+
+func (a *asustor) buttonMap() map[int]Button {
+	return asustorButtons
+}
+
+// StopListening ends the pending Listen calls, the display stays open.
+func (a *asustor) StopListening() {
+	a.listeners.stopAll()
+}
+
+func (a *asustor) ListenEvents(l func(ev ButtonEvent) bool) {
+	a.ListenEventsContext(context.Background(), l)
+}
+
+func (a *asustor) ListenEventsContext(ctx context.Context, l func(ev ButtonEvent) bool) {
+	if !a.open.get() {
+		return
+	}
+	a.listeners.listen(ctx, a.clock.Now(), a.open.get, a.btnC, l)
+}
+
+func (a *asustor) freeze() {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	a.frozen = true
+}
+
+func (a *asustor) closeNotify() <-chan struct{} {
+	return a.state.closeNotify()
+}
+
+func (a *asustor) Framebuffer() *Framebuffer {
+	return a.fb
+}
+
+func (a *asustor) GetLine(line Line) (string, error) {
+	return a.fb.Line(line)
+}
+
+func (a *asustor) Snapshot() ([]string, error) {
+	return a.fb.Lines(), nil
+}
+
+func (a *asustor) ObserveContent(fn func(c Content)) (cancel func()) {
+	return a.fb.Observe(fn)
+}
+
+func (a *asustor) ObserveButtons(fn func(ev ButtonEvent)) (cancel func()) {
+	return a.listeners.observe(fn)
+}
+
+func (a *asustor) DefaultKeymap() Keymap {
+	return AsustorKeymap
+}
+
+func (a *asustor) ConnState() ConnState {
+	return a.state.get()
+}
+
+func (a *asustor) Stats() Stats {
+	return a.counters.stats()
+}
+
+// Errors delivers the errors of the background reader.
+func (a *asustor) Errors() <-chan error {
+	return a.errC
+}
+
+// InjectButton passes a synthesized button event to Listen
+// as if it was sent by the display.
+func (a *asustor) InjectButton(btn int, released bool) error {
+	if !a.open.get() {
+		return ErrClosed
+	}
+	a.queue(btn, released)
+	return nil
+}
+
+// write msg and send it again until it is acknowledged. The acks
+// are all alike, the late ack of an attempt is discarded so it doesn't
+// complete the retry or the next write, see matcher.
+func (a *asustor) write(msg []byte) error {
+	for {
+		if !a.open.get() {
+			return ErrClosed
 		}
-	}()
-	return <-ch
+		end := a.tracer.start("display.roundtrip", "display.retry", int(a.retry))
+		acked, err := true, error(nil)
+		if a.noAck {
+			err = a.flush(msg)
+		} else {
+			acked, err = a.request(msg, a.replies.expect(a.isAck))
+		}
+		if err != nil {
+			end(err)
+			return err
+		}
+		if acked {
+			end(nil)
+			a.retry = 0
+			return nil
+		}
+		end(ErrDisplayNotWorking)
+		if a.retry > a.retries {
+			return ErrDisplayNotWorking
+		}
+		a.retry++
+		a.counters.retried()
+	}
+}
+
+// request sends msg and waits up to the read timeout for the reply p
+// expects.
+func (a *asustor) request(msg []byte, p *pendingReply) (bool, error) {
+	if err := a.flush(msg); err != nil {
+		a.replies.cancel(p)
+		return false, err
+	}
+	ok := a.replies.wait(p, a.readTimeout)
+	if a.open.get() {
+		a.counters.replied(ok)
+	}
+	return ok, nil
+}
+
+// isReady matches the reply to cmdDisplayStatus.
+func (a *asustor) isReady(frame []byte) bool {
+	return bytes.HasPrefix(frame, a.replyRdy)
+}
+
+// isAck matches the acknowledgement of a written frame.
+func (a *asustor) isAck(frame []byte) bool {
+	return bytes.Equal(frame, a.replyMsgSentCheck)
 }
 
 // read reads asynchronously from the serial port
 // and transmits messages on the read or btn channel.
-func (a *asustor) read() {
-	buf := bytes.Buffer{}
-	startFound := false
+func (a *asustor) read(con io.Reader, stop <-chan struct{}, bg *sync.WaitGroup) {
+	defer bg.Done()
+	var err error
+	defer func() {
+		if err != nil {
+			a.state.result(err)
+			reportErr(a.errC, err)
+		}
+	}()
+	defer recovered("read", &err)
+	parser := asustorproto.NewParser()
 	res := make([]byte, 20)
-	for a.open {
-		i, er := a.con.Read(res)
-		if er != nil || !a.open {
+	for {
+		var i int
+		i, err = con.Read(res)
+		select {
+		case <-stop:
+			err = nil
 			return
+		default:
 		}
-		for c := 0; c < i; c++ {
-			if startFound || res[c] == a.replyByte || res[c] == a.cmdByte {
-				startFound = true
-				buf.WriteByte(res[c])
-				if buf.Len() == 5 {
-					startFound = false
-					a.pass(buf.Bytes())
-					buf.Reset()
-				}
-			}
+		if err != nil {
+			return
+		}
+		for _, frame := range parser.Feed(res[:i]) {
+			a.pass(frame)
 		}
 	}
 }
 
+func (a *asustor) queue(btn int, released bool) {
+	if released {
+		a.repeat.release()
+	}
+	send := func(ev ButtonEvent) {
+		a.counters.dropped(a.drop.sendEvent(a.btnC, ev))
+	}
+	a.listeners.emit(btn, released, a.clock.Now(), send)
+	if !released {
+		a.repeat.press(btn, func() {
+			a.listeners.repeat(btn, a.clock.Now(), send)
+		})
+	}
+}
+
 func (a *asustor) pass(res []byte) {
 	//log.Println("read", res)
-	if bytes.HasPrefix(res, a.cmdBtn) {
-		a.btnC <- res
-	} else {
-		a.readC <- res
+	f, _ := asustorproto.Decode(res)
+	if f.Type == asustorproto.TypeCommand && f.Command == asustorproto.CmdButton && len(f.Data) == 1 {
+		// the display reports released buttons only, the press is
+		// synthesized so all drivers deliver press and release
+		btn := int(f.Data[0])
+		a.queue(btn, false)
+		a.queue(btn, true)
+	} else if !a.replies.feed(res) {
+		a.counters.unexpected()
 	}
 }
 
-// write synchronously to the serial port.
+// write an encoded frame synchronously to the serial port.
 func (a *asustor) flush(data []byte) error {
-	data = a.makemsg(data)
-
-	a.waitForFlushBetweenWrites()
-	n, err := a.con.Write(data)
-
+	a.pacer.wait()
+	n, err := writeTimeout(a.clock, a.con, data, a.timeout)
+	a.counters.wrote(err)
+	if err == ErrWriteTimeout {
+		// unblock the pending write, Open reconnects
+		_ = a.forceClose()
+		reportErr(a.errC, err)
+	}
 	if err != nil {
 		return err
 	}
@@ -290,50 +643,39 @@ func (a *asustor) flush(data []byte) error {
 	return err
 }
 
-func (a *asustor) makemsg(msg []byte) []byte {
-	data := make([]byte, len(msg), len(msg)+1)
-	copy(data, msg)
-	data = append(data, checksum(data))
-	return data
-}
-
-func (a *asustor) waitForFlushBetweenWrites() {
-	timeDiff := a.lastFlush.Add(10 * time.Millisecond).Sub(time.Now())
-	if timeDiff > 0 {
-		time.Sleep(timeDiff)
-	}
-	a.lastFlush = time.Now()
-}
-
-func checksum(b []byte) (s byte) {
-	for _, bb := range b {
-		s += bb
-	}
-	return s
-}
-
 func (a *asustor) strToBytes(line Line, text string) []byte {
-	return a.createMsg(line, []byte(prepareTxt(text)))
-}
-
-func (a *asustor) createMsg(line Line, text []byte) []byte {
-	return append([]byte{a.cmdByte, 0x12, 0x27, byte(line), byte(0)}, text...)
+	return asustorproto.Write(byte(line), []byte(fit(text, a.width))).Encode()
 }
 
-// Close the serial connection.
+// Close the serial connection once the last write was processed,
+// for example a shutdown message. It waits up to the close timeout
+// for the listeners and the reader to return.
 func (a *asustor) Close() error {
 	a.m.Lock()
-	defer a.m.Unlock()
-
-	if !a.open {
+	if !a.open.get() {
+		a.m.Unlock()
 		return nil
 	}
-	return a.forceClose()
+	a.state.set(StateClosing)
+	a.pacer.settle()
+	err := a.forceClose()
+	bg := a.bg
+	a.m.Unlock()
+
+	await(a.clock, a.closeTimeout, waitDone(bg), a.listeners.stopped())
+	return err
 }
 
 func (a *asustor) forceClose() error {
-	a.open = false
-	a.readC <- []byte{}
-	a.btnC <- []byte{}
+	a.open.set(false)
+	a.repeat.release()
+	if a.stop != nil {
+		close(a.stop)
+		a.stop = nil
+	}
+	a.state.set(StateClosed)
+	// fail a pending request right away
+	a.replies.cancelAll()
+	a.listeners.wakeAll()
 	return a.con.Close()
 }
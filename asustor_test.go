@@ -0,0 +1,40 @@
+package display
+
+import (
+	"github.com/artvel/display/emulator"
+	"testing"
+	"time"
+)
+
+func TestAsustorStatusKeepsTheDisplayOff(t *testing.T) {
+	emu, con := emulator.NewAsustor()
+	lcd, err := NewAsustorLCDFromConn(con, WithClock(newFakeClock()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lcd.Close()
+
+	if err = lcd.Enable(false); err != nil {
+		t.Fatal(err)
+	}
+	st, err := lcd.(StatusReporter).Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.On || !st.Responsive {
+		t.Errorf("got %+v, want off and responsive", st)
+	}
+	// the emulator switches once it read the command
+	deadline := time.Now().Add(time.Second)
+	for emu.Enabled() {
+		if time.Now().After(deadline) {
+			t.Fatal("display is on")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// a status query sent after the command would turn it on again
+	time.Sleep(10 * time.Millisecond)
+	if emu.Enabled() {
+		t.Error("Status turned the display on")
+	}
+}
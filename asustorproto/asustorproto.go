@@ -0,0 +1,139 @@
+/*
+Package asustorproto implements the serial wire format of the
+ASUSTOR LCD display without any of the serial plumbing.
+It has no dependencies and compiles with TinyGo.
+
+asustor data format:
+
+	MESSAGE_TYPE DATA_LENGTH COMMAND [[DATA]...] [CRC]
+*/
+package asustorproto
+
+import (
+	"errors"
+	"github.com/artvel/display/checksum"
+	"github.com/artvel/display/internal/frame"
+)
+
+type (
+	// Frame is a decoded message without the checksum.
+	Frame struct {
+		Type    byte
+		Command byte
+		Data    []byte
+	}
+	// Parser splits a byte stream into checksum verified frames.
+	Parser struct {
+		p frame.Parser
+	}
+)
+
+// Message types
+const (
+	TypeCommand byte = 240
+	TypeReply   byte = 241
+)
+
+// Commands
+const (
+	// CmdDisplayStatus with data 1 turns the display on and reports the
+	// status, 0 turns it off.
+	CmdDisplayStatus byte = 17
+	CmdClearDisplay  byte = 18
+	CmdDisplayOn     byte = 34
+	// CmdWrite with data LINE 0 TEXT...
+	CmdWrite byte = 39
+	// CmdButton is sent by the display with the button as data.
+	CmdButton byte = 128
+)
+
+const (
+	// Width of a line in characters.
+	Width = 16
+	// Lines of the display.
+	Lines = 2
+	// MaxDataLength of a frame, which is a line of text.
+	MaxDataLength = Width + 2
+	// headerSize is type, length and command.
+	headerSize = 3
+)
+
+var (
+	ErrFrameSize = errors.New("asustor frame size mismatch")
+	ErrChecksum  = errors.New("asustor frame checksum mismatch")
+)
+
+// Encode the frame including the checksum.
+func (f Frame) Encode() []byte {
+	b := make([]byte, 0, headerSize+len(f.Data)+Checksummer.Size())
+	b = append(b, f.Type, byte(len(f.Data)), f.Command)
+	b = append(b, f.Data...)
+	return Checksummer.Append(b, b)
+}
+
+// Decode a complete frame including the checksum.
+func Decode(b []byte) (Frame, error) {
+	if len(b) < headerSize+1 || int(b[1])+headerSize+1 != len(b) {
+		return Frame{}, ErrFrameSize
+	}
+	if !checksum.Verify(Checksummer, b) {
+		return Frame{}, ErrChecksum
+	}
+	return Frame{
+		Type:    b[0],
+		Command: b[2],
+		Data:    append([]byte(nil), b[headerSize:len(b)-1]...),
+	}, nil
+}
+
+// Checksummer of the frames, the sum of all bytes.
+var Checksummer = checksum.Additive
+
+// Checksum is the sum of all bytes.
+func Checksum(b []byte) byte {
+	return Checksummer.Append(nil, b)[0]
+}
+
+// Command creates a command frame.
+func Command(cmd byte, data ...byte) Frame {
+	return Frame{Type: TypeCommand, Command: cmd, Data: data}
+}
+
+// Write creates a frame writing text on line.
+// The text has to be padded to Width by the caller.
+func Write(line byte, text []byte) Frame {
+	return Command(CmdWrite, append([]byte{line, 0}, text...)...)
+}
+
+// NewParser for a stream of frames read from the display.
+func NewParser() *Parser {
+	return &Parser{p: frame.Parser{
+		Start: func(b byte) bool {
+			return b == TypeReply || b == TypeCommand
+		},
+		Size: func(buf []byte) int {
+			if len(buf) < 2 {
+				return 0
+			}
+			if int(buf[1]) > MaxDataLength {
+				// rejected by Valid
+				return 1
+			}
+			return int(buf[1]) + headerSize + 1
+		},
+		Valid: func(b []byte) bool {
+			_, err := Decode(b)
+			return err == nil
+		},
+	}}
+}
+
+// Feed the bytes of a read and get all complete frames encoded.
+func (p *Parser) Feed(data []byte) [][]byte {
+	return p.p.Feed(data)
+}
+
+// Reset drops a partial frame.
+func (p *Parser) Reset() {
+	p.p.Reset()
+}
@@ -0,0 +1,29 @@
+//go:build go1.18
+// +build go1.18
+
+package asustorproto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzParse(f *testing.F) {
+	f.Add(Write(0, []byte("hello")).Encode())
+	f.Add(append([]byte{TypeCommand, 200}, Command(CmdButton, 1).Encode()...))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p := NewParser()
+		// split the data to cover partial frames
+		frames := p.Feed(data[:len(data)/2])
+		frames = append(frames, p.Feed(data[len(data)/2:])...)
+		for _, b := range frames {
+			f, err := Decode(b)
+			if err != nil {
+				t.Fatalf("parser returned invalid frame %x: %v", b, err)
+			}
+			if !bytes.Equal(f.Encode(), b) {
+				t.Fatalf("%x re-encoded to %x", b, f.Encode())
+			}
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package asustorproto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	valid := Write(1, []byte("hello")).Encode()
+	badSum := append([]byte(nil), valid...)
+	badSum[len(badSum)-1]++
+	tests := []struct {
+		name  string
+		frame []byte
+		err   error
+	}{
+		{"valid", valid, nil},
+		{"wrong checksum", badSum, ErrChecksum},
+		{"truncated", valid[:len(valid)-1], ErrFrameSize},
+		{"too short", []byte{TypeReply, 0}, ErrFrameSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Decode(tt.frame)
+			if err != tt.err {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if err == nil && !bytes.Equal(f.Encode(), tt.frame) {
+				t.Errorf("re-encoded %x, want %x", f.Encode(), tt.frame)
+			}
+		})
+	}
+}
+
+func TestParser(t *testing.T) {
+	ack := Frame{Type: TypeReply, Command: CmdWrite, Data: []byte{0}}.Encode()
+	btn := Command(CmdButton, 2).Encode()
+	badSum := append([]byte(nil), ack...)
+	badSum[len(badSum)-1]++
+	cat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	tests := []struct {
+		name  string
+		reads [][]byte
+		want  [][]byte
+	}{
+		{"valid frames", [][]byte{cat(ack, btn)}, [][]byte{ack, btn}},
+		{"wrong checksum", [][]byte{badSum}, nil},
+		{"resync after garbage", [][]byte{cat([]byte{0, TypeReply, 99}, btn)}, [][]byte{btn}},
+		{"resync after wrong checksum", [][]byte{cat(badSum, btn)}, [][]byte{btn}},
+		{"split reads", [][]byte{ack[:2], ack[2:4], cat(ack[4:], btn[:1]), btn[1:]}, [][]byte{ack, btn}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			var got [][]byte
+			for _, r := range tt.reads {
+				got = append(got, p.Feed(r)...)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %x, want %x", got, tt.want)
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], tt.want[i]) {
+					t.Errorf("frame %d is %x, want %x", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
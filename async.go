@@ -0,0 +1,143 @@
+package display
+
+import "sync"
+
+type (
+	async struct {
+		decorator
+		depth int
+		m     sync.Mutex
+		// changed is signaled once the queue shrinks or the worker stops
+		changed *sync.Cond
+		queue   []asyncOp
+		busy    bool
+		closed  bool
+		// err of the first failed write since the last Flush
+		err error
+	}
+
+	// asyncOp is a write or, if enable is set, an Enable call.
+	asyncOp struct {
+		line   Line
+		text   string
+		enable *bool
+	}
+)
+
+// DefaultAsyncDepth of the queue of Async.
+const DefaultAsyncDepth = 32
+
+// Async queues the writes and Enable calls and returns right away,
+// a background worker sends them in order with the pacing of the
+// display. A refresh of several lines no longer blocks the caller for
+// the write delay of each line. Once depth operations are queued, the
+// next one blocks until there is room, zero means DefaultAsyncDepth.
+// Queued writes fail silently, Flush waits for the queue and returns
+// the first error. Close sends the queued operations first.
+func Async(depth int) Middleware {
+	return func(lcd LCD) LCD {
+		d := &async{decorator: decorator{lcd}, depth: intOr(depth, DefaultAsyncDepth)}
+		d.changed = sync.NewCond(&d.m)
+		return d
+	}
+}
+
+func (d *async) Write(line Line, text string) error {
+	return d.push(asyncOp{line: line, text: text})
+}
+
+func (d *async) Enable(yes bool) error {
+	return d.push(asyncOp{enable: &yes})
+}
+
+func (d *async) push(op asyncOp) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	for len(d.queue) >= d.depth && !d.closed {
+		d.changed.Wait()
+	}
+	if d.closed {
+		return ErrClosed
+	}
+	d.queue = append(d.queue, op)
+	if !d.busy {
+		d.busy = true
+		go d.run()
+	}
+	return nil
+}
+
+// run sends the queue and stops once it is empty.
+func (d *async) run() {
+	for {
+		d.m.Lock()
+		if len(d.queue) == 0 {
+			d.busy = false
+			d.changed.Broadcast()
+			d.m.Unlock()
+			return
+		}
+		op := d.queue[0]
+		d.queue = d.queue[1:]
+		d.changed.Broadcast()
+		d.m.Unlock()
+
+		var err error
+		if op.enable != nil {
+			err = d.LCD.Enable(*op.enable)
+		} else {
+			err = d.LCD.Write(op.line, op.text)
+		}
+		if err != nil {
+			d.m.Lock()
+			if d.err == nil {
+				d.err = err
+			}
+			d.m.Unlock()
+		}
+	}
+}
+
+// wait until the queue was sent, must be called with the lock held.
+func (d *async) wait() {
+	for d.busy {
+		d.changed.Wait()
+	}
+}
+
+// Flush waits for the queue and flushes the display.
+func (d *async) Flush() error {
+	d.m.Lock()
+	d.wait()
+	err := d.err
+	d.err = nil
+	d.m.Unlock()
+
+	if f, ok := d.LCD.(Flusher); ok {
+		if ferr := f.Flush(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+func (d *async) Open() error {
+	d.m.Lock()
+	d.closed = false
+	d.m.Unlock()
+
+	return d.LCD.Open()
+}
+
+// Close sends the queued operations first, they might be the last
+// message.
+func (d *async) Close() error {
+	d.m.Lock()
+	d.wait()
+	d.closed = true
+	d.changed.Broadcast()
+	d.m.Unlock()
+
+	return d.LCD.Close()
+}
@@ -0,0 +1,160 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedLCD blocks every write until it is let through by gate.
+type gatedLCD struct {
+	*fakeLCD
+	gate chan struct{}
+	err  error
+}
+
+func (g *gatedLCD) Write(line Line, text string) error {
+	<-g.gate
+	if err := g.fakeLCD.Write(line, text); err != nil {
+		return err
+	}
+	return g.err
+}
+
+func TestAsyncKeepsTheOrder(t *testing.T) {
+	f := newFakeLCD(2, 16)
+	lcd := Async(4)(f)
+	var want []string
+	for i := 0; i < 20; i++ {
+		txt := fmt.Sprint(i)
+		want = append(want, txt)
+		if err := lcd.Write(Line(i%2), txt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = lcd.Enable(false)
+	if err := lcd.(Flusher).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.written(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if f.fb.Enabled() {
+		t.Error("Enable wasn't sent after the writes")
+	}
+}
+
+func TestAsyncFlushWaitsForTheQueue(t *testing.T) {
+	g := &gatedLCD{fakeLCD: newFakeLCD(2, 16), gate: make(chan struct{})}
+	lcd := Async(0)(g)
+	_ = lcd.Write(LineOne, "a")
+	_ = lcd.Write(LineTwo, "b")
+
+	flushed := make(chan error, 1)
+	go func() { flushed <- lcd.(Flusher).Flush() }()
+	g.gate <- struct{}{}
+	select {
+	case <-flushed:
+		t.Fatal("Flush returned with a pending write")
+	case <-time.After(20 * time.Millisecond):
+	}
+	g.gate <- struct{}{}
+	if err := <-flushed; err != nil {
+		t.Fatal(err)
+	}
+	if got := g.written(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestAsyncFlushReturnsTheFirstError(t *testing.T) {
+	gate := make(chan struct{})
+	close(gate)
+	errLink := errors.New("link")
+	g := &gatedLCD{fakeLCD: newFakeLCD(2, 16), gate: gate, err: errLink}
+	lcd := Async(0)(g)
+	_ = lcd.Write(LineOne, "a")
+	if err := lcd.(Flusher).Flush(); err != errLink {
+		t.Errorf("got %v, want the error of the queued write", err)
+	}
+	g.err = nil
+	if err := lcd.(Flusher).Flush(); err != nil {
+		t.Errorf("got %v, the error wasn't reset", err)
+	}
+}
+
+func TestAsyncBlocksWhenFull(t *testing.T) {
+	g := &gatedLCD{fakeLCD: newFakeLCD(2, 16), gate: make(chan struct{})}
+	lcd := Async(1)(g)
+	// the first write is taken by the worker, the second queued
+	_ = lcd.Write(LineOne, "a")
+	_ = lcd.Write(LineOne, "b")
+
+	pushed := make(chan struct{})
+	go func() {
+		_ = lcd.Write(LineOne, "c")
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("write returned on a full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(g.gate)
+	<-pushed
+	_ = lcd.(Flusher).Flush()
+	if got := g.written(); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestAsyncCloseDrains(t *testing.T) {
+	g := &gatedLCD{fakeLCD: newFakeLCD(2, 16), gate: make(chan struct{})}
+	lcd := Async(0)(g)
+	for _, txt := range []string{"a", "b", "bye"} {
+		_ = lcd.Write(LineOne, txt)
+	}
+	closed := make(chan error, 1)
+	go func() { closed <- lcd.Close() }()
+	close(g.gate)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if got := g.written(); !reflect.DeepEqual(got, []string{"a", "b", "bye"}) {
+		t.Errorf("got %q, Close didn't send the queue", got)
+	}
+	if err := lcd.Write(LineOne, "late"); err != ErrClosed {
+		t.Errorf("got %v writing after Close, want ErrClosed", err)
+	}
+}
+
+// run with -race
+func TestAsyncConcurrentWrites(t *testing.T) {
+	f := newFakeLCD(4, 16)
+	lcd := Async(2)(f)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(line Line) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				_ = lcd.Write(line, fmt.Sprint(line, "/", n))
+			}
+		}(Line(i))
+	}
+	wg.Wait()
+	if err := lcd.(Flusher).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(f.written()); got != 200 {
+		t.Errorf("got %d writes, want 200", got)
+	}
+	for i, txt := range f.fb.Lines() {
+		if want := fit(fmt.Sprint(i, "/", 49), 16); txt != want {
+			t.Errorf("line %d is %q, want %q", i, txt, want)
+		}
+	}
+}
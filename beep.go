@@ -0,0 +1,42 @@
+package display
+
+import "time"
+
+type (
+	// BeepPattern alternates between beeping and silence, starting
+	// with a beep.
+	BeepPattern []time.Duration
+	// Beeper is implemented by displays whose controller drives a
+	// buzzer. The serial protocols of the ASUSTOR and QNAP panels have
+	// no known buzzer command, the buzzer of those units is wired to
+	// the mainboard instead.
+	Beeper interface {
+		Beep(pattern BeepPattern) error
+	}
+)
+
+var (
+	BeepShort = BeepPattern{100 * time.Millisecond}
+	BeepAlert = BeepPattern{200 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond}
+)
+
+// Beep with lcd or any display it wraps. Displays without a buzzer
+// return ErrNotSupported.
+func Beep(lcd LCD, pattern BeepPattern) error {
+	for _, l := range chain(lcd) {
+		if b, ok := l.(Beeper); ok {
+			return b.Beep(pattern)
+		}
+	}
+	return ErrNotSupported
+}
+
+// HasBeep reports whether lcd or any display it wraps has a buzzer.
+func HasBeep(lcd LCD) bool {
+	for _, l := range chain(lcd) {
+		if _, ok := l.(Beeper); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package display
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+type (
+	// ButtonLog keeps the latest button events, optionally persisted to
+	// a file, to answer questions like "did somebody press the power
+	// button before it shut down?". Pass it to the drivers with
+	// WithButtonLog.
+	ButtonLog struct {
+		m    sync.Mutex
+		ring []ButtonEvent
+		next int
+		full bool
+		f    *os.File
+	}
+	// buttonLogEntry is a line of the log file
+	buttonLogEntry struct {
+		Time     time.Time     `json:"time"`
+		Button   string        `json:"button"`
+		Raw      int           `json:"raw"`
+		Released bool          `json:"released"`
+		Held     time.Duration `json:"held,omitempty"`
+	}
+)
+
+// DefaultButtonLogSize is used for sizes below 1.
+const DefaultButtonLogSize = 256
+
+// NewButtonLog keeping the latest size events in memory.
+func NewButtonLog(size int) *ButtonLog {
+	if size < 1 {
+		size = DefaultButtonLogSize
+	}
+	return &ButtonLog{ring: make([]ButtonEvent, size)}
+}
+
+// OpenButtonLog keeps the latest size events like NewButtonLog and
+// appends every event as JSON line to the file at path. The events
+// already in the file are loaded, so they survive a reboot. Rotate the
+// file with the tools of the system.
+func OpenButtonLog(path string, size int) (*ButtonLog, error) {
+	l := NewButtonLog(size)
+	if f, err := os.Open(path); err == nil {
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			var e buttonLogEntry
+			if json.Unmarshal(s.Bytes(), &e) == nil {
+				l.add(ButtonEvent{Button: parseButton(e.Button), Raw: e.Raw, Released: e.Released, Time: e.Time, Held: e.Held})
+			}
+		}
+		_ = f.Close()
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	l.f = f
+	return l, nil
+}
+
+// WithButtonLog records all button events of the driver in l, no
+// matter if somebody listens.
+func WithButtonLog(l *ButtonLog) Option {
+	return func(o *options) {
+		o.buttonLog = l
+	}
+}
+
+// Record ev, the drivers call it for every event. Repeats are skipped.
+func (l *ButtonLog) Record(ev ButtonEvent) {
+	if ev.Repeat {
+		return
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.add(ev)
+	if l.f == nil {
+		return
+	}
+	b, err := json.Marshal(buttonLogEntry{Time: ev.Time, Button: ev.Button.String(), Raw: ev.Raw, Released: ev.Released, Held: ev.Held})
+	if err == nil {
+		_, _ = l.f.Write(append(b, '\n'))
+	}
+}
+
+// Recent events since the time, the oldest first.
+func (l *ButtonLog) Recent(since time.Time) []ButtonEvent {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	var res []ButtonEvent
+	n := l.next
+	if l.full {
+		n = len(l.ring)
+	}
+	for i := 0; i < n; i++ {
+		ev := l.ring[i]
+		if l.full {
+			ev = l.ring[(l.next+i)%len(l.ring)]
+		}
+		if !ev.Time.Before(since) {
+			res = append(res, ev)
+		}
+	}
+	return res
+}
+
+// Close the file of the log.
+func (l *ButtonLog) Close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+func (l *ButtonLog) add(ev ButtonEvent) {
+	l.ring[l.next] = ev
+	l.next = (l.next + 1) % len(l.ring)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// parseButton is the reverse of Button.String.
+func parseButton(s string) Button {
+	for b := ButtonUp; b <= ButtonEnter; b++ {
+		if b.String() == s {
+			return b
+		}
+	}
+	return ButtonUnknown
+}
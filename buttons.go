@@ -0,0 +1,405 @@
+package display
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type (
+	// Button identifies a physical button independent of the device.
+	Button int
+
+	// ButtonEvent describes a single press or release of a button.
+	ButtonEvent struct {
+		Button Button
+		// Raw is the device specific id as passed to Listen.
+		Raw      int
+		Released bool
+		// Time the display reported the event.
+		Time time.Time
+		// Held is the time the button was held down. It is set on
+		// release, ASUSTOR panels report the release only and it is 0.
+		Held time.Duration
+		// Repeat is set for the presses synthesized while the button
+		// is held, see WithAutoRepeat.
+		Repeat bool
+	}
+
+	// EventListener is implemented by displays delivering ButtonEvents.
+	// It replaces Listen with its raw button ids.
+	EventListener interface {
+		// ListenEvents blocks and passes all button events to l
+		// until l returns false or the display is closed.
+		ListenEvents(l func(ev ButtonEvent) bool)
+	}
+
+	// ListenStopper is implemented by the drivers. StopListening
+	// ends all pending Listen calls as if their listener returned
+	// false, without closing the display. Listen can be called again
+	// afterwards, like after a listener returned false.
+	ListenStopper interface {
+		StopListening()
+	}
+
+	// ContextListener is implemented by displays which can stop
+	// listening from the outside, for example on a timeout.
+	ContextListener interface {
+		// ListenEventsContext works like ListenEvents but returns
+		// as well once ctx is done.
+		ListenEventsContext(ctx context.Context, l func(ev ButtonEvent) bool)
+	}
+
+	// buttonMapper is implemented by the drivers, buttonMap maps
+	// their raw button ids
+	buttonMapper interface {
+		buttonMap() map[int]Button
+	}
+
+	// events turns the raw button actions of a driver into ButtonEvents.
+	events struct {
+		buttons map[int]Button
+		pressed map[int]time.Time
+	}
+
+	// listeners delivers the button events of a driver to the most
+	// recent of several concurrent ListenEvents calls. The others pause
+	// until it returns, so dialogs and widgets can take over the
+	// buttons temporarily.
+	listeners struct {
+		// order is held while an event is made and sent, so a repeat
+		// can't overtake the release of its button
+		order     sync.Mutex
+		m         sync.Mutex
+		events    events
+		stack     []chan ButtonEvent
+		observers map[int]func(ev ButtonEvent)
+		nextID    int
+		// closed once the stack is empty
+		waiters []chan struct{}
+		// generation is increased by stopAll, listeners of an older
+		// one return
+		generation int
+		// last event delivered to a listener
+		last ButtonEvent
+	}
+
+	// listener is a ListenEvents call of a driver.
+	listener struct {
+		c   chan ButtonEvent
+		gen int
+		// skipping the rest of the press which opened a nested
+		// listener, its raw button is skip
+		skipping bool
+		skip     int
+	}
+)
+
+const (
+	ButtonUnknown Button = iota
+	ButtonUp
+	ButtonDown
+	// ButtonBoth is reported if up and down are held together.
+	ButtonBoth
+	ButtonBack
+	ButtonEnter
+)
+
+// wakeUp is sent to blocked listeners on close
+var wakeUp = ButtonEvent{}
+
+// ListenEvents blocks and passes the button events of lcd to l.
+// Displays not implementing EventListener are adapted through Listen,
+// their events lack the Button and Held.
+func ListenEvents(lcd LCD, l func(ev ButtonEvent) bool) {
+	if el, ok := lcd.(EventListener); ok {
+		el.ListenEvents(l)
+		return
+	}
+	lcd.Listen(func(btn int, released bool) bool {
+		return l(ButtonEvent{Raw: btn, Released: released, Time: ClockOf(lcd).Now()})
+	})
+}
+
+// ListenEventsContext blocks and passes the button events of lcd to l
+// until l returns false or ctx is done. Displays not implementing
+// ContextListener can't be stopped, the next event after ctx is done
+// is dropped to end their Listen.
+func ListenEventsContext(ctx context.Context, lcd LCD, l func(ev ButtonEvent) bool) {
+	if cl, ok := lcd.(ContextListener); ok {
+		cl.ListenEventsContext(ctx, l)
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ListenEvents(lcd, func(ev ButtonEvent) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			return l(ev)
+		})
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// ButtonOf translates the raw id lcd passes to Listen, like
+// AsustorRawEnter or QnapRawBoth, ButtonUnknown if it can't. ButtonEvents
+// carry the Button already.
+func ButtonOf(lcd LCD, raw int) Button {
+	for _, l := range chain(lcd) {
+		if m, ok := l.(buttonMapper); ok {
+			return m.buttonMap()[raw]
+		}
+	}
+	return ButtonUnknown
+}
+
+// StopListening ends the pending Listen calls of lcd or the display it
+// wraps, ErrNotSupported if it can't.
+func StopListening(lcd LCD) error {
+	for _, l := range chain(lcd) {
+		if s, ok := l.(ListenStopper); ok {
+			s.StopListening()
+			return nil
+		}
+	}
+	return ErrNotSupported
+}
+
+func (b Button) String() string {
+	switch b {
+	case ButtonUp:
+		return "up"
+	case ButtonDown:
+		return "down"
+	case ButtonBoth:
+		return "both"
+	case ButtonBack:
+		return "back"
+	case ButtonEnter:
+		return "enter"
+	}
+	return fmt.Sprintf("unknown(%d)", int(b))
+}
+
+func newEvents(buttons map[int]Button) events {
+	return events{buttons: buttons, pressed: map[int]time.Time{}}
+}
+
+// event for the raw button, keeping track of the held buttons.
+func (e *events) event(btn int, released bool, at time.Time) ButtonEvent {
+	ev := ButtonEvent{Button: e.buttons[btn], Raw: btn, Released: released, Time: at}
+	if !released {
+		e.pressed[btn] = at
+	} else if pressed, ok := e.pressed[btn]; ok {
+		ev.Held = at.Sub(pressed)
+		delete(e.pressed, btn)
+	}
+	return ev
+}
+
+func newListeners(buttons map[int]Button) *listeners {
+	return &listeners{events: newEvents(buttons), observers: map[int]func(ev ButtonEvent){}}
+}
+
+// event converts a raw button reported by the driver
+// and passes it to all observers.
+func (s *listeners) event(btn int, released bool, at time.Time) ButtonEvent {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	ev := s.events.event(btn, released, at)
+	for _, fn := range s.observers {
+		fn(ev)
+	}
+	return ev
+}
+
+// emit the event of a raw button to the observers and send.
+func (s *listeners) emit(btn int, released bool, at time.Time, send func(ev ButtonEvent)) {
+	s.order.Lock()
+	defer s.order.Unlock()
+
+	send(s.event(btn, released, at))
+}
+
+// repeat the press of the held raw button, pass it to all observers
+// and send. It is dropped if the button was released meanwhile.
+func (s *listeners) repeat(btn int, at time.Time, send func(ev ButtonEvent)) {
+	s.order.Lock()
+	defer s.order.Unlock()
+
+	s.m.Lock()
+	pressed, ok := s.events.pressed[btn]
+	if !ok {
+		s.m.Unlock()
+		return
+	}
+	ev := ButtonEvent{Button: s.events.buttons[btn], Raw: btn, Time: at, Repeat: true, Held: at.Sub(pressed)}
+	for _, fn := range s.observers {
+		fn(ev)
+	}
+	s.m.Unlock()
+
+	send(ev)
+}
+
+// observe calls fn with every event until cancel is called.
+func (s *listeners) observe(fn func(ev ButtonEvent)) (cancel func()) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	id := s.nextID
+	s.nextID++
+	s.observers[id] = fn
+	return func() {
+		s.m.Lock()
+		defer s.m.Unlock()
+
+		delete(s.observers, id)
+	}
+}
+
+// listen implements ListenEventsContext on top of the button channel c
+// of a driver. It returns once l returns false, ctx is done or open
+// reports false. A listener opened by a press, like a dialog, drops
+// the repeats and the release of that press queued before since,
+// otherwise it would act on them.
+func (s *listeners) listen(ctx context.Context, since time.Time, open func() bool, c <-chan ButtonEvent, l func(ev ButtonEvent) bool) {
+	me := s.push()
+	defer s.pop(me.c)
+	for open() && s.current(me.gen) {
+		var ev ButtonEvent
+		select {
+		case ev = <-c:
+		case ev = <-me.c:
+		case <-ctx.Done():
+			return
+		}
+		if !open() || !s.current(me.gen) {
+			return
+		}
+		if ev == wakeUp || me.stale(ev, since) || s.forward(me.c, ev) {
+			continue
+		}
+		s.delivered(ev)
+		if !l(ev) {
+			return
+		}
+	}
+}
+
+// stale reports if ev is part of the press which opened the listener.
+func (me *listener) stale(ev ButtonEvent, since time.Time) bool {
+	if !me.skipping || ev.Raw != me.skip {
+		return false
+	}
+	if ev.Released || !ev.Time.Before(since) {
+		me.skipping = false
+	}
+	return ev.Time.Before(since)
+}
+
+func (s *listeners) delivered(ev ButtonEvent) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.last = ev
+}
+
+// forward ev to the top listener if it isn't me.
+func (s *listeners) forward(me chan ButtonEvent, ev ButtonEvent) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	top := s.stack[len(s.stack)-1]
+	if top == me {
+		return false
+	}
+	select {
+	case top <- ev:
+	default:
+	}
+	return true
+}
+
+// push a listener, it is nested if there is one already and skips
+// the rest of the press delivered last.
+func (s *listeners) push() *listener {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	me := &listener{c: make(chan ButtonEvent, 10), gen: s.generation}
+	if len(s.stack) > 0 && !s.last.Released && s.last != wakeUp {
+		me.skipping, me.skip = true, s.last.Raw
+	}
+	s.stack = append(s.stack, me.c)
+	return me
+}
+
+// current reports if gen wasn't stopped.
+func (s *listeners) current(gen int) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.generation == gen
+}
+
+// stopAll ends the pending listeners, the next ones listen again.
+func (s *listeners) stopAll() {
+	s.m.Lock()
+	s.generation++
+	s.m.Unlock()
+
+	s.wakeAll()
+}
+
+func (s *listeners) pop(me chan ButtonEvent) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for i, l := range s.stack {
+		if l == me {
+			s.stack = append(s.stack[:i], s.stack[i+1:]...)
+			break
+		}
+	}
+	if len(s.stack) == 0 {
+		for _, w := range s.waiters {
+			close(w)
+		}
+		s.waiters = nil
+	}
+}
+
+// stopped returns a channel which is closed once no listener is left.
+func (s *listeners) stopped() <-chan struct{} {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	c := make(chan struct{})
+	if len(s.stack) == 0 {
+		close(c)
+	} else {
+		s.waiters = append(s.waiters, c)
+	}
+	return c
+}
+
+// wakeAll blocked listeners, for example on close.
+func (s *listeners) wakeAll() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for _, l := range s.stack {
+		select {
+		case l <- wakeUp:
+		default:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package display
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// collect events of s from c until n arrived.
+func collect(s *listeners, since time.Time, c <-chan ButtonEvent, n int) []ButtonEvent {
+	var got []ButtonEvent
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.listen(ctx, since, func() bool { return true }, c, func(ev ButtonEvent) bool {
+		got = append(got, ev)
+		return len(got) < n
+	})
+	return got
+}
+
+func TestListenKeepsQueuedEvents(t *testing.T) {
+	s := newListeners(dummyButtons)
+	c := make(chan ButtonEvent, 10)
+	start := time.Now()
+	c <- s.event(int(ButtonUp), false, start)
+	c <- s.event(int(ButtonUp), true, start)
+	if got := collect(s, start.Add(time.Second), c, 2); len(got) != 2 {
+		t.Errorf("got %v, want the press and release queued before", got)
+	}
+}
+
+func TestNestedListenSkipsThePressWhichOpenedIt(t *testing.T) {
+	s := newListeners(dummyButtons)
+	c := make(chan ButtonEvent, 10)
+	start := time.Now()
+	c <- s.event(int(ButtonEnter), false, start)
+	c <- s.event(int(ButtonEnter), true, start)
+	c <- s.event(int(ButtonDown), false, start)
+	c <- s.event(int(ButtonDown), true, start)
+	var nested []ButtonEvent
+	// the press of enter opens a dialog listening on its own
+	s.listen(context.Background(), start, func() bool { return true }, c, func(ev ButtonEvent) bool {
+		nested = collect(s, start.Add(time.Millisecond), c, 2)
+		return false
+	})
+	if len(nested) != 2 || nested[0].Button != ButtonDown || nested[1].Button != ButtonDown {
+		t.Errorf("dialog got %v, want the down button only", nested)
+	}
+}
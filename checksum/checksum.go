@@ -0,0 +1,84 @@
+/*
+Package checksum has the checksum algorithms of the panel protocols.
+The frame encoders take a Checksummer, so a new driver only has to
+pick or implement its algorithm. Like the protocol packages it has
+no dependencies and compiles with TinyGo.
+*/
+package checksum
+
+type (
+	// Checksummer computes the checksum appended to a frame.
+	Checksummer interface {
+		// Size of the checksum in bytes.
+		Size() int
+		// Append the checksum of data to b.
+		Append(b, data []byte) []byte
+	}
+	additive struct{}
+	xor      struct{}
+	crc16    struct{}
+)
+
+var (
+	// Additive is the sum of all bytes, used by ASUSTOR.
+	Additive Checksummer = additive{}
+	// XOR of all bytes.
+	XOR Checksummer = xor{}
+	// CRC16 is the CCITT CRC of CrystalFontz modules (CRC-16/X-25),
+	// appended least significant byte first.
+	CRC16 Checksummer = crc16{}
+)
+
+// Verify reports whether frame ends with the checksum of the bytes in
+// front of it.
+func Verify(c Checksummer, frame []byte) bool {
+	n := len(frame) - c.Size()
+	if n < 0 {
+		return false
+	}
+	sum := c.Append(nil, frame[:n])
+	for i, b := range sum {
+		if frame[n+i] != b {
+			return false
+		}
+	}
+	return true
+}
+
+func (additive) Size() int { return 1 }
+
+func (additive) Append(b, data []byte) []byte {
+	var s byte
+	for _, d := range data {
+		s += d
+	}
+	return append(b, s)
+}
+
+func (xor) Size() int { return 1 }
+
+func (xor) Append(b, data []byte) []byte {
+	var s byte
+	for _, d := range data {
+		s ^= d
+	}
+	return append(b, s)
+}
+
+func (crc16) Size() int { return 2 }
+
+func (crc16) Append(b, data []byte) []byte {
+	crc := uint16(0xffff)
+	for _, d := range data {
+		crc ^= uint16(d)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0x8408
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	crc = ^crc
+	return append(b, byte(crc), byte(crc>>8))
+}
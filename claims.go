@@ -0,0 +1,182 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type (
+	// Arbiter settles the fights of several subsystems writing to the
+	// same display, like pages, alerts and menus. Each of them claims
+	// the lines it needs with a priority, the claim with the highest
+	// priority owns a line and the writes of the others are rejected
+	// with ErrLineClaimed. Their text is kept and shown once the line
+	// is released. Plain writes to the Arbiter have the lowest claim.
+	Arbiter struct {
+		decorator
+		m      sync.Mutex
+		claims []*Claim
+		base   *Claim
+	}
+	// Claim on lines of an Arbiter. It is a display itself, writing
+	// with the priority of the claim, so it can be handed to a
+	// subsystem. Close releases the claim instead of the display.
+	Claim struct {
+		decorator
+		a        *Arbiter
+		name     string
+		priority int
+		// lines claimed, nil claims the whole panel
+		lines []Line
+		// text written per line, shown once the line is owned
+		text    map[Line]string
+		enabled *bool
+	}
+)
+
+// ErrLineClaimed is returned for writes to a line owned by a claim
+// with a higher priority.
+var ErrLineClaimed = errors.New("line claimed")
+
+// NewArbiter for lcd, use it instead of lcd.
+func NewArbiter(lcd LCD) *Arbiter {
+	a := &Arbiter{decorator: decorator{lcd}}
+	a.base = a.Claim("", 0)
+	return a
+}
+
+// Claim lines for name with priority, without lines the whole panel is
+// claimed. A later claim wins over one of the same priority.
+func (a *Arbiter) Claim(name string, priority int, lines ...Line) *Claim {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	c := &Claim{decorator: decorator{a}, a: a, name: name, priority: priority, lines: lines, text: map[Line]string{}}
+	a.claims = append(a.claims, c)
+	return c
+}
+
+func (a *Arbiter) Write(line Line, text string) error {
+	return a.base.Write(line, text)
+}
+
+func (a *Arbiter) Enable(yes bool) error {
+	return a.base.Enable(yes)
+}
+
+// owner of line, must be called with the lock held.
+func (a *Arbiter) owner(line Line) *Claim {
+	var res *Claim
+	for _, c := range a.claims {
+		if c.covers(line) && (res == nil || c.priority >= res.priority) {
+			res = c
+		}
+	}
+	return res
+}
+
+// lineCount of the display.
+func (a *Arbiter) lineCount() int {
+	if fb, ok := FramebufferOf(a.LCD); ok {
+		return len(fb.Lines())
+	}
+	return 2
+}
+
+// Name of the claim.
+func (c *Claim) Name() string {
+	return c.name
+}
+
+func (c *Claim) covers(line Line) bool {
+	if c.lines == nil {
+		return true
+	}
+	for _, l := range c.lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+// Write line if the claim owns it, otherwise the text is kept for
+// later and ErrLineClaimed is returned.
+func (c *Claim) Write(line Line, text string) error {
+	c.a.m.Lock()
+	defer c.a.m.Unlock()
+
+	c.text[line] = text
+	if owner := c.a.owner(line); owner != c {
+		return c.claimed(owner)
+	}
+	return c.a.LCD.Write(line, text)
+}
+
+// Enable the display if the claim owns all lines.
+func (c *Claim) Enable(yes bool) error {
+	c.a.m.Lock()
+	defer c.a.m.Unlock()
+
+	c.enabled = &yes
+	for i := 0; i < c.a.lineCount(); i++ {
+		if owner := c.a.owner(Line(i)); owner != c {
+			return c.claimed(owner)
+		}
+	}
+	return c.a.LCD.Enable(yes)
+}
+
+func (c *Claim) claimed(owner *Claim) error {
+	if owner == nil {
+		return ErrLineClaimed
+	}
+	return fmt.Errorf("%w by %q", ErrLineClaimed, owner.name)
+}
+
+// Release the claim, the lines go back to the next owner and show its
+// text again.
+func (c *Claim) Release() error {
+	a := c.a
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for i, other := range a.claims {
+		if other == c {
+			a.claims = append(a.claims[:i], a.claims[i+1:]...)
+			break
+		}
+	}
+	var err error
+	all := true
+	var next *Claim
+	for i := 0; i < a.lineCount(); i++ {
+		line := Line(i)
+		owner := a.owner(line)
+		if owner == nil || !c.covers(line) {
+			all = false
+			continue
+		}
+		if next == nil {
+			next = owner
+		}
+		all = all && owner == next
+		if text, ok := owner.text[line]; ok {
+			if werr := a.LCD.Write(line, text); werr != nil && err == nil {
+				err = werr
+			}
+		}
+	}
+	if all && next != nil && next.enabled != nil {
+		if eerr := a.LCD.Enable(*next.enabled); eerr != nil && err == nil {
+			err = eerr
+		}
+	}
+	return err
+}
+
+// Close releases the claim, the display stays open.
+func (c *Claim) Close() error {
+	return c.Release()
+}
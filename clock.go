@@ -0,0 +1,52 @@
+package display
+
+import "time"
+
+type (
+	// Clock is the source of time for pacing, retries, timeouts and the
+	// timers of the helpers. Tests replace it with WithClock to make
+	// time dependent logic deterministic.
+	Clock interface {
+		Now() time.Time
+		Sleep(d time.Duration)
+		After(d time.Duration) <-chan time.Time
+		AfterFunc(d time.Duration, f func()) Timer
+	}
+	// Timer is a pending AfterFunc call.
+	Timer interface {
+		Stop() bool
+	}
+	// clocked is implemented by the drivers, the helpers and wrappers
+	// use the clock of the display they wrap.
+	clocked interface {
+		timeSource() Clock
+	}
+	realClock struct{}
+)
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// WithClock replaces the real clock of the driver and of everything
+// wrapping it.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		if c != nil {
+			o.clock = c
+		}
+	}
+}
+
+// ClockOf lcd or any display it wraps, the real clock if none has one.
+func ClockOf(lcd LCD) Clock {
+	for _, l := range chain(lcd) {
+		if c, ok := l.(clocked); ok {
+			return c.timeSource()
+		}
+	}
+	return realClock{}
+}
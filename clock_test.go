@@ -0,0 +1,33 @@
+package display
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestWrappersUseTheClockOfTheDisplay(t *testing.T) {
+	c := newFakeClock()
+	lcd := NewLoggingDummy(ioutil.Discard, WithClock(c))
+
+	throttled := Throttle(50 * time.Millisecond)(lcd)
+	start := c.Now()
+	for i := 0; i < 3; i++ {
+		if err := throttled.Write(LineOne, "x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := c.Now().Sub(start); got != 100*time.Millisecond {
+		t.Errorf("throttled writes took %v, want 100ms", got)
+	}
+
+	s := NewScreensaver(lcd, time.Minute)
+	c.Advance(59 * time.Second)
+	if s.Asleep() {
+		t.Fatal("screensaver slept too early")
+	}
+	c.Advance(time.Second)
+	if !s.Asleep() {
+		t.Error("screensaver didn't sleep after the idle time")
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/artvel/display"
+	"github.com/artvel/display/pages"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type (
+	// Config of the daemon, see LoadConfig. Flags given on the command
+	// line override it.
+	//
+	//	{
+	//		"display": {"model": "qnap", "tty": "/dev/ttyS1", "offlineBuffer": 2},
+	//		"interval": "5s",
+	//		"http": ":8080",
+	//		"pages": {"nut": "ups@localhost", "sensors": ["coretemp.0/temp1:CPU:80"]},
+	//		"actions": {"poweroff": "systemctl poweroff"},
+	//		"schedules": [{"off": "23:00", "on": "07:00"}]
+	//	}
+	Config struct {
+		Display  DisplayConfig `json:"display"`
+		Interval Duration      `json:"interval"`
+		// HTTP is the address to serve the panel on, a bare :port
+		// listens on localhost only.
+		HTTP string `json:"http"`
+		// HTTPToken allows writes and button presses over HTTP for
+		// requests sending it as bearer token.
+		HTTPToken    string        `json:"httpToken"`
+		Alertmanager bool          `json:"alertmanager"`
+		Pages        PagesConfig   `json:"pages"`
+		Actions      ActionsConfig `json:"actions"`
+		// Schedules turn the display off, for example at night.
+		Schedules []Schedule `json:"schedules"`
+		// Locale of the built-in strings, like de.
+		Locale string `json:"locale"`
+		// Theme of the cursors and bars, like ascii.
+		Theme string `json:"theme"`
+	}
+
+	DisplayConfig struct {
+		// Model is auto, asustor or qnap.
+		Model string `json:"model"`
+		TTY   string `json:"tty"`
+		// Baud overrides the baud rate of the driver.
+		Baud          uint     `json:"baud"`
+		WriteDelay    Duration `json:"writeDelay"`
+		WriteTimeout  Duration `json:"writeTimeout"`
+		ReadTimeout   Duration `json:"readTimeout"`
+		OfflineBuffer int      `json:"offlineBuffer"`
+		// ButtonLog is a file recording all button events.
+		ButtonLog string `json:"buttonLog"`
+		// StatsInterval logs the counters of the driver, like retries
+		// and reconnects, every interval.
+		StatsInterval Duration `json:"statsInterval"`
+	}
+
+	PagesConfig struct {
+		NUT   string `json:"nut"`
+		HWMon bool   `json:"hwmon"`
+		// Sensors like chip/input[:label[:limit]], implies HWMon.
+		Sensors []string `json:"sensors"`
+		IPMI    string   `json:"ipmi"`
+		Docker  bool     `json:"docker"`
+		NTP     bool     `json:"ntp"`
+		// Plugins are commands speaking the protocol of pages.Plugin.
+		Plugins []string `json:"plugins"`
+		// Scripts are Starlark files, see package script.
+		Scripts []string `json:"scripts"`
+		// Tails show the latest matching line of log files.
+		Tails []TailConfig `json:"tails"`
+		// HTTP endpoints rendered with templates, see pages.HTTP.
+		HTTP []HTTPConfig `json:"http"`
+	}
+
+	TailConfig struct {
+		Path string `json:"path"`
+		// Pattern is a regular expression, all lines match if empty.
+		Pattern string `json:"pattern"`
+		Title   string `json:"title"`
+	}
+
+	HTTPConfig struct {
+		URL      string            `json:"url"`
+		Title    string            `json:"title"`
+		Lines    []string          `json:"lines"`
+		Headers  map[string]string `json:"headers"`
+		Interval Duration          `json:"interval"`
+	}
+
+	ActionsConfig struct {
+		// PowerOff command run after holding both buttons.
+		PowerOff string `json:"poweroff"`
+	}
+
+	// Schedule turns the display off at Off and on again at On, both
+	// like 23:00.
+	Schedule struct {
+		Off string `json:"off"`
+		On  string `json:"on"`
+	}
+
+	// Duration is a time.Duration written like 5s in the config.
+	Duration struct {
+		time.Duration
+	}
+)
+
+func defaultConfig() *Config {
+	return &Config{
+		Display:  DisplayConfig{Model: "auto"},
+		Interval: Duration{pages.DefaultInterval},
+	}
+}
+
+// LoadConfig reads the JSON config at path, unset values keep their
+// defaults.
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := defaultConfig()
+	if err = json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err = c.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	switch c.Display.Model {
+	case "auto", "asustor", "qnap":
+	default:
+		return fmt.Errorf("unknown display model %q", c.Display.Model)
+	}
+	if _, err := c.sensors(); err != nil {
+		return err
+	}
+	if _, err := c.tails(); err != nil {
+		return err
+	}
+	if _, err := c.httpSources(); err != nil {
+		return err
+	}
+	for _, s := range c.Schedules {
+		if _, _, err := s.window(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) sensors() ([]pages.Sensor, error) {
+	var res []pages.Sensor
+	for _, s := range c.Pages.Sensors {
+		sn, err := pages.ParseSensor(s)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, sn)
+	}
+	return res, nil
+}
+
+func (c *Config) tails() ([]*pages.Tail, error) {
+	var res []*pages.Tail
+	for _, t := range c.Pages.Tails {
+		tail := &pages.Tail{Path: t.Path, Title: t.Title}
+		if t.Pattern != "" {
+			re, err := regexp.Compile(t.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("tail %s: %v", t.Path, err)
+			}
+			tail.Pattern = re
+		}
+		res = append(res, tail)
+	}
+	return res, nil
+}
+
+func (c *Config) httpSources() ([]*pages.HTTP, error) {
+	var res []*pages.HTTP
+	for _, h := range c.Pages.HTTP {
+		src := &pages.HTTP{URL: h.URL, Title: h.Title, Lines: h.Lines, Header: http.Header{}, PollInterval: h.Interval.Duration}
+		for k, v := range h.Headers {
+			src.Header.Set(k, v)
+		}
+		if err := src.Parse(); err != nil {
+			return nil, fmt.Errorf("http %s: %v", h.URL, err)
+		}
+		res = append(res, src)
+	}
+	return res, nil
+}
+
+// options for the display driver.
+func (d DisplayConfig) options() []display.Option {
+	var opts []display.Option
+	if d.TTY != "" {
+		opts = append(opts, display.WithTTY(d.TTY))
+	}
+	if d.Baud > 0 {
+		opts = append(opts, display.WithBaudRate(d.Baud))
+	}
+	if d.WriteDelay.Duration > 0 {
+		opts = append(opts, display.WithWriteDelay(d.WriteDelay.Duration))
+	}
+	if d.WriteTimeout.Duration > 0 {
+		opts = append(opts, display.WithWriteTimeout(d.WriteTimeout.Duration))
+	}
+	if d.ReadTimeout.Duration > 0 {
+		opts = append(opts, display.WithReadTimeout(d.ReadTimeout.Duration))
+	}
+	if d.OfflineBuffer > 0 {
+		opts = append(opts, display.WithOfflineBuffer(d.OfflineBuffer))
+	}
+	return opts
+}
+
+// window of the schedule in minutes of the day.
+func (s Schedule) window() (off, on int, err error) {
+	if off, err = minuteOfDay(s.Off); err != nil {
+		return
+	}
+	on, err = minuteOfDay(s.On)
+	return
+}
+
+// active reports if the display is off at t, the window may span
+// midnight.
+func (s Schedule) active(t time.Time) bool {
+	off, on, err := s.window()
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	if off <= on {
+		return now >= off && now < on
+	}
+	return now >= off || now < on
+}
+
+func minuteOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("schedule time %q: want hh:mm", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// tailList collects the -tail flags like path[:pattern].
+type tailList []TailConfig
+
+func (l *tailList) String() string {
+	return fmt.Sprint(*l)
+}
+
+func (l *tailList) Set(s string) error {
+	path, pattern := s, ""
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		path, pattern = s[:i], s[i+1:]
+	}
+	*l = append(*l, TailConfig{Path: path, Pattern: pattern})
+	return nil
+}
@@ -0,0 +1,13 @@
+module github.com/artvel/display/cmd/displayd
+
+go 1.16
+
+replace (
+	github.com/artvel/display => ../..
+	github.com/artvel/display/script => ../../script
+)
+
+require (
+	github.com/artvel/display v0.0.0-00010101000000-000000000000
+	github.com/artvel/display/script v0.0.0-00010101000000-000000000000
+)
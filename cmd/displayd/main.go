@@ -0,0 +1,304 @@
+// Command displayd runs the front panel of a NAS. It shows status
+// pages in rotation and optionally serves the panel over HTTP,
+// including an endpoint for Prometheus Alertmanager webhooks.
+//
+//	displayd -http 0.0.0.0:8080 -alertmanager
+//
+// and point an Alertmanager webhook receiver to
+// http://nas:8080/alertmanager. A bare -http :8080 listens on
+// localhost only. Appliance images rather use a config
+// file, see Config:
+//
+//	displayd -config /etc/displayd.json
+//
+// It is a module of its own as it runs Starlark scripts.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/artvel/display"
+	"github.com/artvel/display/pages"
+	"github.com/artvel/display/script"
+	"github.com/artvel/display/web"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	cfg := defaultConfig()
+	configPath := flag.String("config", "", "load the JSON config file, flags override it")
+	bindFlags(cfg)
+	flag.Parse()
+	if *configPath != "" {
+		loaded, err := LoadConfig(*configPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*cfg = *loaded
+		// the flags given on the command line win
+		_ = flag.CommandLine.Parse(os.Args[1:])
+	}
+	// the flags are validated as well as the file
+	if err := cfg.validate(); err != nil {
+		log.Fatal(err)
+	}
+	sensors, err := cfg.sensors()
+	if err != nil {
+		log.Fatal(err)
+	}
+	tails, err := cfg.tails()
+	if err != nil {
+		log.Fatal(err)
+	}
+	httpSources, err := cfg.httpSources()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cfg.Locale != "" {
+		if err = display.SetLocale(cfg.Locale); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if cfg.Theme != "" {
+		if err = display.SetTheme(cfg.Theme); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	lcd, err := open(ctx, cfg.Display)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer lcd.Close()
+
+	name, _ := os.Hostname()
+	_ = display.ShowBootSplash(lcd, name, "")
+
+	board := pages.NewBoard(lcd, cfg.Interval.Duration)
+	sched := pages.NewScheduler(board, host{})
+	if p := cfg.Pages; p.NUT != "" {
+		sched.Add(pages.ParseNUT(p.NUT))
+	}
+	if cfg.Pages.HWMon || len(sensors) > 0 {
+		sched.Add(&pages.HWMon{Sensors: sensors})
+	}
+	if cfg.Pages.IPMI != "" {
+		sched.Add(&pages.IPMI{Command: strings.Fields(cfg.Pages.IPMI)})
+	}
+	if cfg.Pages.Docker {
+		sched.Add(&pages.Docker{})
+	}
+	if cfg.Pages.NTP {
+		sched.Add(&pages.NTP{})
+	}
+	for _, cmd := range cfg.Pages.Plugins {
+		plugin := &pages.Plugin{Command: strings.Fields(cmd)}
+		defer plugin.Close()
+		sched.Add(plugin)
+	}
+	for _, t := range tails {
+		sched.Add(t)
+	}
+	for _, h := range httpSources {
+		sched.Add(h)
+	}
+	for _, path := range cfg.Pages.Scripts {
+		s := &script.Script{Path: path, Board: board}
+		sched.Add(s)
+		go s.Watch(ctx, lcd)
+	}
+	go sched.Run(ctx)
+
+	if cfg.Actions.PowerOff != "" {
+		args := strings.Fields(cfg.Actions.PowerOff)
+		go display.PowerOff{
+			Action: func() error {
+				return exec.Command(args[0], args[1:]...).Run()
+			},
+			Started: board.Pause,
+		}.Watch(ctx, lcd)
+	}
+	if len(cfg.Schedules) > 0 {
+		go schedule(ctx, lcd, board, cfg.Schedules)
+	}
+	if d := cfg.Display.StatsInterval.Duration; d > 0 {
+		go logStats(ctx, lcd, d)
+	}
+
+	if cfg.HTTP != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/", web.NewHandler(lcd, web.WithControl(cfg.HTTPToken)))
+		if cfg.Alertmanager {
+			mux.Handle("/alertmanager", web.NewAlertmanagerHandler(board))
+		}
+		srv := &http.Server{Addr: listenAddr(cfg.HTTP), Handler: mux}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Println(err)
+			}
+		}()
+		defer srv.Close()
+	}
+
+	_ = board.Run(ctx)
+	_ = display.ShowShutdown(lcd)
+}
+
+// listenAddr binds a bare :port to localhost, the panel
+// must not be reachable from the network by accident.
+func listenAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("localhost", port)
+}
+
+// bindFlags to the fields of c.
+func bindFlags(c *Config) {
+	flag.StringVar(&c.Display.Model, "model", c.Display.Model, "display model: auto, asustor or qnap")
+	flag.StringVar(&c.Display.TTY, "tty", c.Display.TTY, "serial port of the display, defaults to "+display.DefaultTTy)
+	flag.StringVar(&c.Display.ButtonLog, "button-log", c.Display.ButtonLog, "record all button events in this file")
+	flag.StringVar(&c.Locale, "locale", c.Locale, "language of the built-in strings: en, de or fr")
+	flag.StringVar(&c.Theme, "theme", c.Theme, "look of the cursors and bars: default, ascii or arrows")
+	flag.DurationVar(&c.Interval.Duration, "interval", c.Interval.Duration, "time each page is shown")
+	flag.StringVar(&c.HTTP, "http", c.HTTP, "serve the panel over HTTP on this address, a bare :port listens on localhost only")
+	flag.StringVar(&c.HTTPToken, "http-token", c.HTTPToken, "allow writes and button presses over HTTP with this bearer token")
+	flag.BoolVar(&c.Alertmanager, "alertmanager", c.Alertmanager, "accept Alertmanager webhooks on /alertmanager, requires -http")
+	flag.StringVar(&c.Pages.NUT, "nut", c.Pages.NUT, "show the UPS upsname[@hostname[:port]] of a NUT server")
+	flag.BoolVar(&c.Pages.HWMon, "hwmon", c.Pages.HWMon, "show temperatures and fans")
+	flag.StringVar(&c.Pages.IPMI, "ipmi", c.Pages.IPMI, "show IPMI sensors using this ipmitool command, like \"ipmitool -I lanplus -H bmc\"")
+	flag.BoolVar(&c.Pages.Docker, "docker", c.Pages.Docker, "show the containers of the local Docker engine")
+	flag.BoolVar(&c.Pages.NTP, "ntp", c.Pages.NTP, "show the time sync state of chrony or ntpd")
+	flag.StringVar(&c.Actions.PowerOff, "poweroff", c.Actions.PowerOff, "command to power off after holding both buttons, like \"systemctl poweroff\"")
+	flag.Var((*stringList)(&c.Pages.Sensors), "sensor", "show only this hwmon sensor chip/input[:label[:limit]], repeatable")
+	flag.Var((*tailList)(&c.Pages.Tails), "tail", "show the latest line of the log file path[:regexp] matching regexp, repeatable")
+	flag.Var((*stringList)(&c.Pages.Scripts), "script", "run the page and button handlers of this Starlark script, repeatable")
+	flag.Var((*stringList)(&c.Pages.Plugins), "plugin", "show the pages of this plugin command, see pages.Plugin, repeatable")
+}
+
+// open the display, auto detection goes on in the background
+// until the display shows up, for example after a late serial driver.
+func open(ctx context.Context, c DisplayConfig) (display.LCD, error) {
+	opts := c.options()
+	if c.ButtonLog != "" {
+		l, err := display.OpenButtonLog(c.ButtonLog, 0)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, display.WithButtonLog(l))
+	}
+	switch c.Model {
+	case "asustor":
+		return display.NewAsustorLCD(c.TTY, opts...)
+	case "qnap":
+		return display.NewQnapLCD(c.TTY, opts...)
+	}
+	lcd, found := display.FindAsync(ctx, opts...)
+	go func() {
+		for l := range found {
+			log.Printf("display attached: %T", l)
+		}
+	}()
+	return lcd, nil
+}
+
+// schedule turns the display off while a schedule is active and
+// pauses the board meanwhile.
+func schedule(ctx context.Context, lcd display.LCD, board *pages.Board, schedules []Schedule) {
+	tick := time.NewTicker(time.Minute)
+	defer tick.Stop()
+	var resume func()
+	for {
+		off := false
+		for _, s := range schedules {
+			off = off || s.active(time.Now())
+		}
+		if off && resume == nil {
+			resume = board.Pause()
+			_ = lcd.Enable(false)
+		} else if !off && resume != nil {
+			_ = lcd.Enable(true)
+			resume()
+			resume = nil
+		}
+		select {
+		case <-ctx.Done():
+			if resume != nil {
+				resume()
+			}
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// logStats of the driver every interval until ctx is done.
+func logStats(ctx context.Context, lcd display.LCD, interval time.Duration) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+		if st, err := display.StatsOf(lcd); err == nil {
+			log.Printf("display: %s", st)
+		}
+	}
+}
+
+// host shows the host name and address.
+type host struct{}
+
+func (host) Name() string {
+	return "host"
+}
+
+func (host) Interval() time.Duration {
+	return time.Minute
+}
+
+func (host) Poll(ctx context.Context) ([]string, error) {
+	name, err := os.Hostname()
+	return []string{name, localIP()}, err
+}
+
+func localIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	for _, a := range addrs {
+		if ip, ok := a.(*net.IPNet); ok && !ip.IP.IsLoopback() && ip.IP.To4() != nil {
+			return ip.IP.String()
+		}
+	}
+	return ""
+}
+
+// stringList collects repeated flags.
+type stringList []string
+
+func (l *stringList) String() string {
+	return fmt.Sprint(*l)
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
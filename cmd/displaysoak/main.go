@@ -0,0 +1,145 @@
+// Command displaysoak hammers a panel with random writes, enable
+// toggles and reconnects and reports the error rates, to validate the
+// pacing and retry settings on a firmware revision before a release.
+//
+//	displaysoak -tty /dev/ttyS1 -duration 4h -delay 15ms
+//
+// It stops early on SIGINT or SIGTERM and prints the final report.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/artvel/display"
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// counter of an operation
+type counter struct {
+	runs   int
+	errors map[string]int
+}
+
+type soak struct {
+	ops   map[string]*counter
+	start time.Time
+}
+
+func main() {
+	tty := flag.String("tty", "", "serial port, probed if empty")
+	duration := flag.Duration("duration", time.Hour, "how long to run")
+	delay := flag.Duration("delay", 0, "write delay, 0 keeps the default of the driver")
+	reconnect := flag.Duration("reconnect", 5*time.Minute, "close and open the display this often, 0 never")
+	every := flag.Duration("report", time.Minute, "report interval")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed of the random operations")
+	flag.Parse()
+
+	var opts []display.Option
+	if *tty != "" {
+		opts = append(opts, display.WithTTY(*tty))
+	}
+	if *delay > 0 {
+		opts = append(opts, display.WithWriteDelay(*delay))
+	}
+	lcd, info, err := display.FindStrict(opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("soaking %s on %s for %v, seed %d", info.Driver, info.TTY, *duration, *seed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s := &soak{ops: map[string]*counter{}, start: time.Now()}
+	rnd := rand.New(rand.NewSource(*seed))
+	report := time.NewTicker(*every)
+	defer report.Stop()
+	lastReconnect := time.Now()
+	enabled := true
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-report.C:
+			s.print(lcd)
+		default:
+		}
+		switch {
+		case *reconnect > 0 && time.Since(lastReconnect) > *reconnect:
+			lastReconnect = time.Now()
+			s.run("close", lcd.Close)
+			s.run("open", lcd.Open)
+		case rnd.Intn(10) == 0:
+			enabled = !enabled
+			s.run("enable", func() error { return lcd.Enable(enabled) })
+		default:
+			line := display.Line(rnd.Intn(2))
+			s.run("write", func() error { return lcd.Write(line, randomText(rnd)) })
+		}
+	}
+	s.run("enable", func() error { return lcd.Enable(true) })
+	s.print(lcd)
+	if err = lcd.Close(); err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *soak) run(op string, fn func() error) {
+	c := s.ops[op]
+	if c == nil {
+		c = &counter{errors: map[string]int{}}
+		s.ops[op] = c
+	}
+	c.runs++
+	if err := fn(); err != nil {
+		c.errors[err.Error()]++
+	}
+}
+
+func (s *soak) print(lcd display.LCD) {
+	names := make([]string, 0, len(s.ops))
+	for name := range s.ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	fmt.Fprintf(&b, "after %v:\n", time.Since(s.start).Round(time.Second))
+	for _, name := range names {
+		c := s.ops[name]
+		failed := 0
+		for _, n := range c.errors {
+			failed += n
+		}
+		fmt.Fprintf(&b, "  %-7s %8d runs %6d errors (%.3f%%)\n", name, c.runs, failed, 100*float64(failed)/float64(c.runs))
+		for msg, n := range c.errors {
+			fmt.Fprintf(&b, "          %6d %s\n", n, msg)
+		}
+	}
+	if st, err := display.StatsOf(lcd); err == nil {
+		fmt.Fprintf(&b, "  %s\n", st)
+	}
+	log.Print(b.String())
+}
+
+// randomText of printable characters and progress bars.
+func randomText(rnd *rand.Rand) string {
+	if rnd.Intn(4) == 0 {
+		return display.Progress(rnd.Intn(101))
+	}
+	b := make([]byte, rnd.Intn(17))
+	for i := range b {
+		b[i] = byte(' ' + rnd.Intn('~'-' '+1))
+	}
+	return string(b)
+}
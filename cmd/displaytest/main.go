@@ -0,0 +1,46 @@
+//go:build hardware
+// +build hardware
+
+// Command displaytest runs the hardware suite of package hwtest on an
+// attached panel and exits with 1 if a step failed.
+//
+//	go run -tags hardware ./cmd/displaytest -tty /dev/ttyS1
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/artvel/display"
+	"github.com/artvel/display/hwtest"
+	"log"
+	"os"
+	"time"
+)
+
+func main() {
+	tty := flag.String("tty", "", "serial port, probed if empty")
+	buttons := flag.Duration("buttons", 30*time.Second, "time to press a button, 0 skips the step")
+	selftest := flag.Bool("selftest", false, "cycle the test patterns of SelfTest as well")
+	flag.Parse()
+
+	var opts []display.Option
+	if *tty != "" {
+		opts = append(opts, display.WithTTY(*tty))
+	}
+	lcd, info, err := display.FindStrict(opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer lcd.Close()
+	fmt.Printf("%s on %s\n", info.Driver, info.TTY)
+
+	report := hwtest.Run(lcd, hwtest.Options{ButtonTimeout: *buttons})
+	if *selftest {
+		report = append(report, display.SelfTest(lcd, time.Second)...)
+	}
+	fmt.Print(report)
+	if !report.OK() {
+		lcd.Close()
+		os.Exit(1)
+	}
+}
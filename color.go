@@ -0,0 +1,31 @@
+package display
+
+// Colorer is implemented by displays with an RGB backlight, like the
+// Grove RGB LCD or RGB backlit Matrix Orbital modules. The ASUSTOR and
+// QNAP panels have a fixed backlight and don't implement it.
+type Colorer interface {
+	SetColor(r, g, b uint8) error
+}
+
+// SetColor of the backlight of lcd or any display it wraps, for
+// example red while an alert is shown. Displays without an RGB
+// backlight return ErrNotSupported.
+func SetColor(lcd LCD, r, g, b uint8) error {
+	for _, l := range chain(lcd) {
+		if c, ok := l.(Colorer); ok {
+			return c.SetColor(r, g, b)
+		}
+	}
+	return ErrNotSupported
+}
+
+// HasColor reports whether lcd or any display it wraps has an RGB
+// backlight.
+func HasColor(lcd LCD) bool {
+	for _, l := range chain(lcd) {
+		if _, ok := l.(Colorer); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package display
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ConfirmOptions customize a confirmation dialog.
+type ConfirmOptions struct {
+	// Yes and No labels default to MsgYes and MsgNo of the locale.
+	Yes string
+	No  string
+	// Default is the preselected answer, which is also returned
+	// if the dialog times out.
+	Default bool
+	// Timeout defaults to DefaultConfirmTimeout.
+	Timeout time.Duration
+	// Keymap defaults to the DefaultKeymap of the display.
+	Keymap Keymap
+}
+
+// DefaultConfirmTimeout after which a dialog returns its default answer.
+const DefaultConfirmTimeout = 30 * time.Second
+
+// Confirm shows prompt with a yes/no choice preselecting no and blocks
+// until the user answered or DefaultConfirmTimeout passed.
+// Up and down move the selection, select answers and back means no.
+// The previous screen is restored afterwards.
+func Confirm(lcd LCD, prompt string) (bool, error) {
+	return ConfirmWith(lcd, prompt, ConfirmOptions{})
+}
+
+// ConfirmWith works like Confirm with custom options.
+func ConfirmWith(lcd LCD, prompt string, o ConfirmOptions) (bool, error) {
+	if o.Yes == "" {
+		o.Yes = Tr(MsgYes)
+	}
+	if o.No == "" {
+		o.No = Tr(MsgNo)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), durationOr(o.Timeout, DefaultConfirmTimeout))
+	defer cancel()
+
+	if prev, err := SaveState(lcd); err == nil {
+		defer func() {
+			_ = RestoreState(lcd, prev)
+		}()
+	}
+
+	answer := o.Default
+	width := WidthOf(lcd)
+	render := func() error {
+		if err := lcd.Write(LineOne, prompt); err != nil {
+			return err
+		}
+		cursor := CurrentTheme().Cursor
+		blank := strings.Repeat(" ", utf8.RuneCountInString(cursor))
+		yes, no := blank+o.Yes, blank+o.No
+		if answer {
+			yes = cursor + o.Yes
+		} else {
+			no = cursor + o.No
+		}
+		return lcd.Write(LineTwo, center(yes+"  "+no, width))
+	}
+	if err := render(); err != nil {
+		return false, err
+	}
+	var err error
+	answered := false
+	ListenKeysContext(ctx, lcd, o.Keymap, func(key Key, ev ButtonEvent) bool {
+		switch key {
+		case KeySelect:
+			answered = true
+			return false
+		case KeyBack:
+			answer, answered = false, true
+			return false
+		case KeyUp, KeyDown:
+			answer = !answer
+		}
+		err = render()
+		return err == nil
+	})
+	if err != nil || answered {
+		return answer, err
+	}
+	if ctx.Err() != nil {
+		return o.Default, nil
+	}
+	return false, ErrClosed
+}
@@ -0,0 +1,59 @@
+package display
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Countdown shows the time left of d as mm:ss on line and calls onDone
+// once it reached zero, for example for the grace period of a
+// shutdown. The line is written once a second only. stop ends the
+// countdown early without calling onDone, combine it with ListenEvents
+// for a "press a button to cancel" flow. Write errors are ignored, the
+// countdown keeps running.
+func Countdown(lcd LCD, line Line, d time.Duration, onDone func()) (stop func()) {
+	c := ClockOf(lcd)
+	width := WidthOf(lcd)
+	deadline := c.Now().Add(d)
+	stopC := make(chan struct{})
+	go func() {
+		shown := ""
+		for {
+			left := deadline.Sub(c.Now())
+			if txt := countdownText(left, width); txt != shown {
+				_ = lcd.Write(line, txt)
+				shown = txt
+			}
+			if left <= 0 {
+				if onDone != nil {
+					onDone()
+				}
+				return
+			}
+			// wake up when the next second starts
+			next := left % time.Second
+			if next == 0 {
+				next = time.Second
+			}
+			select {
+			case <-c.After(next):
+			case <-stopC:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stopC) })
+	}
+}
+
+// countdownText rounds up, so zero is only shown once the time is up.
+func countdownText(left time.Duration, width int) string {
+	if left < 0 {
+		left = 0
+	}
+	secs := int((left + time.Second - 1) / time.Second)
+	return center(fmt.Sprintf("%02d:%02d", secs/60, secs%60), width)
+}
@@ -0,0 +1,66 @@
+package display
+
+import (
+	"errors"
+	"sort"
+)
+
+// DisabledPolicy decides what happens to writes while a display is
+// disabled. Left to the firmware, the QNAP panel turned itself on with
+// every write while the ASUSTOR panel stayed dark.
+type DisabledPolicy int
+
+const (
+	// RetainWhileDisabled keeps the writes in the framebuffer and
+	// shows them once the display is enabled again.
+	RetainWhileDisabled DisabledPolicy = iota
+	// RejectWhileDisabled fails the writes with ErrDisabled.
+	RejectWhileDisabled
+)
+
+var ErrDisabled = errors.New("display disabled")
+
+// WithDisabledPolicy sets the behaviour of writes while the display
+// is disabled, default is RetainWhileDisabled.
+func WithDisabledPolicy(p DisabledPolicy) Option {
+	return func(o *options) {
+		o.disabledPolicy = p
+	}
+}
+
+// retained writes of a disabled display, the drivers hold their lock.
+// Only the latest write per line is kept.
+type retained struct {
+	policy DisabledPolicy
+	lines  map[Line]string
+}
+
+// write text on a disabled display, it fails with ErrDisabled unless
+// it is retained.
+func (r *retained) write(line Line, text string) error {
+	if r.policy == RejectWhileDisabled {
+		return ErrDisabled
+	}
+	if r.lines == nil {
+		r.lines = map[Line]string{}
+	}
+	r.lines[line] = text
+	return nil
+}
+
+// replay the retained writes with write once the display is enabled.
+// Lines which failed are kept for the next time.
+func (r *retained) replay(write func(line Line, text string) error) error {
+	lines := make([]Line, 0, len(r.lines))
+	for line := range r.lines {
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i] < lines[j] })
+	for _, line := range lines {
+		if err := write(line, r.lines[line]); err != nil {
+			return err
+		}
+		delete(r.lines, line)
+	}
+	return nil
+}
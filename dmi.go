@@ -0,0 +1,60 @@
+package display
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// knownModel maps the DMI data of a NAS to its panel.
+type knownModel struct {
+	// vendor and product are matched case insensitive as prefix,
+	// an empty product matches all of the vendor.
+	vendor, product string
+	driver, tty     string
+	quirks          Quirks
+}
+
+// knownModels, the first match wins. Add models with a different port
+// or quirks before the fallback of their vendor.
+var knownModels = []knownModel{
+	{vendor: "ASUSTOR", product: "AS6404T", driver: "Asustor", tty: "/dev/ttyS1"},
+	{vendor: "ASUSTOR", driver: "Asustor", tty: "/dev/ttyS1"},
+	{vendor: "QNAP", product: "TVS-", driver: "Qnap", tty: "/dev/ttyS1"},
+	{vendor: "QNAP", driver: "Qnap", tty: "/dev/ttyS1"},
+}
+
+// dmiRoot holds the DMI data exported by Linux
+var dmiRoot = "/sys/class/dmi/id"
+
+// DetectModel reads the DMI data of the machine and reports the driver
+// and port of its panel if the model is known. Find uses it to probe
+// only the driver of the vendor, the init frames of the other one
+// confuse some panels.
+func DetectModel() (DeviceInfo, bool) {
+	vendors := []string{dmi("sys_vendor"), dmi("board_vendor")}
+	products := []string{dmi("product_name"), dmi("board_name")}
+	for _, m := range knownModels {
+		if hasPrefixFold(vendors, m.vendor) && (m.product == "" || hasPrefixFold(products, m.product)) {
+			return DeviceInfo{Driver: m.driver, TTY: m.tty, Quirks: m.quirks}, true
+		}
+	}
+	return DeviceInfo{}, false
+}
+
+func dmi(name string) string {
+	b, err := ioutil.ReadFile(filepath.Join(dmiRoot, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func hasPrefixFold(values []string, prefix string) bool {
+	for _, v := range values {
+		if len(v) >= len(prefix) && strings.EqualFold(v[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package emulator
+
+import (
+	"github.com/artvel/display/asustorproto"
+	"io"
+	"net"
+)
+
+// Asustor emulates the ASUSTOR LCD firmware.
+// It answers the status query, which also turns it on,
+// acknowledges writes and sends button frames on Press.
+type Asustor struct {
+	screen
+	con net.Conn
+}
+
+// NewAsustor starts the emulated display and returns the
+// connection to be used by the driver.
+func NewAsustor() (*Asustor, io.ReadWriteCloser) {
+	driver, device := net.Pipe()
+	a := &Asustor{screen: newScreen(2), con: device}
+	go a.serve()
+	return a, driver
+}
+
+// Press sends a button frame like the firmware does
+// once a button was released.
+func (a *Asustor) Press(btn byte) error {
+	_, err := a.con.Write(asustorproto.Command(asustorproto.CmdButton, btn).Encode())
+	return err
+}
+
+// Close disconnects the display from the driver.
+func (a *Asustor) Close() error {
+	return a.con.Close()
+}
+
+func (a *Asustor) serve() {
+	parser := asustorproto.NewParser()
+	buf := make([]byte, 64)
+	for {
+		n, err := a.con.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, raw := range parser.Feed(buf[:n]) {
+			f, err := asustorproto.Decode(raw)
+			if err != nil || f.Type != asustorproto.TypeCommand {
+				continue
+			}
+			if reply, ok := a.handle(f); ok {
+				if _, err = a.con.Write(reply.Encode()); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *Asustor) handle(f asustorproto.Frame) (reply asustorproto.Frame, ok bool) {
+	reply = asustorproto.Frame{Type: asustorproto.TypeReply, Command: f.Command, Data: []byte{0}}
+	switch f.Command {
+	case asustorproto.CmdDisplayStatus:
+		// like the firmware, any data but 0 turns the display on
+		if len(f.Data) == 1 && f.Data[0] == 0 {
+			a.enable(false)
+			return reply, false
+		}
+		a.enable(true)
+		reply.Data[0] = 1
+		return reply, true
+	case asustorproto.CmdDisplayOn:
+		a.enable(true)
+	case asustorproto.CmdClearDisplay:
+		a.clear()
+	case asustorproto.CmdWrite:
+		if len(f.Data) < 2 || !a.set(int(f.Data[0]), string(f.Data[2:])) {
+			reply.Data[0] = 1
+		}
+		return reply, true
+	}
+	return reply, false
+}
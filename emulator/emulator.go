@@ -0,0 +1,70 @@
+/*
+Package emulator implements the firmware behaviour of the supported
+displays on an in-memory pipe. Pass the returned connection to
+display.NewAsustorLCDFromConn or display.NewQnapLCDFromConn to run
+the full drivers without hardware, for example in CI.
+*/
+package emulator
+
+import (
+	"strings"
+	"sync"
+)
+
+// screen is the state both emulated displays share.
+type screen struct {
+	m       sync.Mutex
+	lines   []string
+	enabled bool
+}
+
+func newScreen(lines int) screen {
+	return screen{lines: make([]string, lines), enabled: true}
+}
+
+// Lines returns the text currently shown, trailing spaces removed.
+func (s *screen) Lines() []string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	res := make([]string, len(s.lines))
+	for i, l := range s.lines {
+		res[i] = strings.TrimRight(l, " ")
+	}
+	return res
+}
+
+// Enabled reports if the display is turned on.
+func (s *screen) Enabled() bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.enabled
+}
+
+func (s *screen) set(line int, text string) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if line < 0 || line >= len(s.lines) {
+		return false
+	}
+	s.lines[line] = text
+	return true
+}
+
+func (s *screen) enable(yes bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.enabled = yes
+}
+
+func (s *screen) clear() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for i := range s.lines {
+		s.lines[i] = ""
+	}
+}
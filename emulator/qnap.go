@@ -0,0 +1,101 @@
+package emulator
+
+import (
+	"bufio"
+	"github.com/artvel/display/qnapproto"
+	"io"
+	"net"
+)
+
+// Qnap emulates the QNAP LCD firmware.
+// It reports ready on init, shows written text and
+// sends button reports on Press and Release.
+type Qnap struct {
+	screen
+	con net.Conn
+}
+
+// NewQnap starts the emulated display and returns the
+// connection to be used by the driver.
+func NewQnap() (*Qnap, io.ReadWriteCloser) {
+	driver, device := net.Pipe()
+	q := &Qnap{screen: newScreen(2), con: device}
+	go q.serve()
+	return q, driver
+}
+
+// Press reports a button as held down.
+// Use qnapproto.ButtonUp, ButtonDown or ButtonBoth.
+func (q *Qnap) Press(btn byte) error {
+	return q.report(qnapproto.ReportButton, btn)
+}
+
+// Release reports all buttons as released.
+func (q *Qnap) Release() error {
+	return q.report(qnapproto.ReportButton, qnapproto.ButtonReleased)
+}
+
+// Close disconnects the display from the driver.
+func (q *Qnap) Close() error {
+	return q.con.Close()
+}
+
+func (q *Qnap) report(report, value byte) error {
+	_, err := q.con.Write(qnapproto.Frame{Report: report, Value: value}.Encode())
+	return err
+}
+
+func (q *Qnap) serve() {
+	r := bufio.NewReader(q.con)
+	for q.handle(r) == nil {
+	}
+}
+
+// handle reads and executes a single command.
+func (q *Qnap) handle(r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil || b != qnapproto.ByteCommand {
+		return err
+	}
+	if b, err = r.ReadByte(); err != nil {
+		return err
+	}
+	switch b {
+	case 0:
+		return q.report(qnapproto.ReportReady, qnapproto.ReadyValue)
+	case 12:
+		return q.write(r)
+	case 94:
+		on, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		q.enable(on == 1)
+		next, err := r.Peek(1)
+		if err != nil {
+			return err
+		}
+		if next[0] == 10 {
+			_, err = r.ReadByte()
+		}
+		return err
+	}
+	return nil
+}
+
+func (q *Qnap) write(r *bufio.Reader) error {
+	line, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	size, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	text := make([]byte, size)
+	if _, err = io.ReadFull(r, text); err != nil {
+		return err
+	}
+	q.set(int(line), string(text))
+	return nil
+}
@@ -0,0 +1,54 @@
+package display
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+)
+
+// The environment variables override the probing of Find, FindStrict
+// and FindAsync, so appliance images can be configured without code
+// changes. They take precedence over the options.
+const (
+	// EnvTTY is the serial port to probe instead of DefaultTTy.
+	EnvTTY = "DISPLAY_TTY"
+	// EnvDriver limits the probing to Asustor or Qnap, case
+	// insensitive. Dummy skips the probing and uses the DummyLCD.
+	EnvDriver = "DISPLAY_DRIVER"
+	// EnvBaud overrides the baud rate of the driver.
+	EnvBaud = "DISPLAY_BAUD"
+)
+
+var errUnknownDriver = errors.New("unknown driver")
+
+// WithTTY sets the serial port probed by Find instead of DefaultTTy.
+func WithTTY(tty string) Option {
+	return func(o *options) {
+		o.tty = tty
+	}
+}
+
+// WithBaudRate overrides the baud rate of the serial port, for panels
+// running a different firmware.
+func WithBaudRate(baud uint) Option {
+	return func(o *options) {
+		o.baudRate = baud
+	}
+}
+
+// withEnv appends the options of the environment.
+func withEnv(opts []Option) []Option {
+	if tty := os.Getenv(EnvTTY); tty != "" {
+		opts = append(opts, WithTTY(tty))
+	}
+	if baud := os.Getenv(EnvBaud); baud != "" {
+		n, err := strconv.ParseUint(baud, 10, 32)
+		if err != nil {
+			log.Printf("ignoring %s: %v", EnvBaud, err)
+		} else {
+			opts = append(opts, WithBaudRate(uint(n)))
+		}
+	}
+	return opts
+}
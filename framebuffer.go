@@ -0,0 +1,262 @@
+package display
+
+import "sync"
+
+type (
+	// Content is what a display currently shows.
+	Content struct {
+		Lines   []string
+		Enabled bool
+	}
+
+	// Observable is implemented by displays reporting changes of their
+	// content and all button events, for example to mirror a panel.
+	// The callbacks must not block.
+	Observable interface {
+		// ObserveContent calls fn with the current content right away
+		// and after every change until cancel is called.
+		ObserveContent(fn func(c Content)) (cancel func())
+		// ObserveButtons calls fn with every button event, no matter
+		// if somebody listens, until cancel is called.
+		ObserveButtons(fn func(ev ButtonEvent)) (cancel func())
+	}
+
+	// ContentReader is implemented by displays which know what they
+	// show. Neither of the supported devices can be asked, the drivers
+	// return what was last written successfully.
+	ContentReader interface {
+		// GetLine returns the text of line as shown, padded to the width.
+		GetLine(line Line) (string, error)
+		// Snapshot returns the text of all lines.
+		Snapshot() ([]string, error)
+	}
+
+	// Framebuffer keeps track of what a display currently shows, as
+	// the devices can't be asked for it. The drivers update it after
+	// every successful write, diffing, SaveState, the snapshots and the
+	// mirrors of the web and render packages read it. It is safe for
+	// concurrent use, the observers are called with the lock held and
+	// must not block.
+	Framebuffer struct {
+		m         sync.Mutex
+		lines     []string
+		enabled   bool
+		observers map[int]func(c Content)
+		nextID    int
+	}
+	// Shadowed is implemented by displays with a Framebuffer.
+	Shadowed interface {
+		Framebuffer() *Framebuffer
+	}
+)
+
+// FramebufferOf lcd or any display it wraps, ok is false if none of
+// them keeps track of its content.
+func FramebufferOf(lcd LCD) (fb *Framebuffer, ok bool) {
+	for _, l := range chain(lcd) {
+		if s, ok := l.(Shadowed); ok {
+			return s.Framebuffer(), true
+		}
+	}
+	return nil, false
+}
+
+// ContentOf lcd or any display it wraps.
+func ContentOf(lcd LCD) (Content, error) {
+	fb, ok := FramebufferOf(lcd)
+	if !ok {
+		return Content{}, ErrNotSupported
+	}
+	return fb.Content(), nil
+}
+
+// ObserveContent of lcd, ok is false if it isn't Observable.
+func ObserveContent(lcd LCD, fn func(c Content)) (cancel func(), ok bool) {
+	for _, l := range chain(lcd) {
+		if o, ok := l.(Observable); ok {
+			return o.ObserveContent(fn), true
+		}
+	}
+	return func() {}, false
+}
+
+// ObserveButtons of lcd, ok is false if it isn't Observable.
+func ObserveButtons(lcd LCD, fn func(ev ButtonEvent)) (cancel func(), ok bool) {
+	for _, l := range chain(lcd) {
+		if o, ok := l.(Observable); ok {
+			return o.ObserveButtons(fn), true
+		}
+	}
+	return func() {}, false
+}
+
+// GetLine of lcd or any display it wraps.
+func GetLine(lcd LCD, line Line) (string, error) {
+	for _, l := range chain(lcd) {
+		if r, ok := l.(ContentReader); ok {
+			return r.GetLine(line)
+		}
+	}
+	return "", ErrNotSupported
+}
+
+// Snapshot of lcd or any display it wraps.
+func Snapshot(lcd LCD) ([]string, error) {
+	for _, l := range chain(lcd) {
+		if r, ok := l.(ContentReader); ok {
+			return r.Snapshot()
+		}
+	}
+	return nil, ErrNotSupported
+}
+
+// NewFramebuffer of a display with the number of lines, it starts
+// empty and enabled.
+func NewFramebuffer(lines int) *Framebuffer {
+	return &Framebuffer{
+		lines:     make([]string, lines),
+		enabled:   true,
+		observers: map[int]func(c Content){},
+	}
+}
+
+// Set the text of line as shown, padded by the driver. It reports
+// whether the line changed, the observers are only called then.
+func (f *Framebuffer) Set(line Line, txt string) bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if int(line) < 0 || int(line) >= len(f.lines) || f.lines[line] == txt {
+		return false
+	}
+	f.lines[line] = txt
+	f.notify()
+	return true
+}
+
+// SetEnabled sets whether the display is on.
+func (f *Framebuffer) SetEnabled(yes bool) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.enabled != yes {
+		f.enabled = yes
+		f.notify()
+	}
+}
+
+// Enabled reports if the display is on.
+func (f *Framebuffer) Enabled() bool {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.enabled
+}
+
+// Line returns the text of line, ErrUnsupportedLine if the display
+// doesn't have it.
+func (f *Framebuffer) Line(line Line) (string, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if int(line) < 0 || int(line) >= len(f.lines) {
+		return "", ErrUnsupportedLine
+	}
+	return f.lines[line], nil
+}
+
+// Lines returns the text of all lines.
+func (f *Framebuffer) Lines() []string {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return append([]string(nil), f.lines...)
+}
+
+// Content including the enabled state.
+func (f *Framebuffer) Content() Content {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.snapshot()
+}
+
+// Diff returns the lines of want differing from what is shown. The
+// text is compared as the drivers show it, cut and padded.
+func (f *Framebuffer) Diff(want []string) []Line {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	var res []Line
+	for i, txt := range want {
+		if i >= len(f.lines) || f.lines[i] != prepareTxt(txt) {
+			res = append(res, Line(i))
+		}
+	}
+	return res
+}
+
+// Observe calls fn with the current content and every change until
+// cancel is called.
+func (f *Framebuffer) Observe(fn func(c Content)) (cancel func()) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	id := f.nextID
+	f.nextID++
+	f.observers[id] = fn
+	fn(f.snapshot())
+	return func() {
+		f.m.Lock()
+		defer f.m.Unlock()
+
+		delete(f.observers, id)
+	}
+}
+
+// resize to the number of lines of another display, the lines it
+// doesn't have are dropped and new lines start empty.
+func (f *Framebuffer) resize(lines int) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if len(f.lines) == lines {
+		return
+	}
+	next := make([]string, lines)
+	copy(next, f.lines)
+	f.lines = next
+	f.notify()
+}
+
+func (f *Framebuffer) snapshot() Content {
+	return Content{Lines: append([]string(nil), f.lines...), Enabled: f.enabled}
+}
+
+func (f *Framebuffer) notify() {
+	if len(f.observers) == 0 {
+		return
+	}
+	c := f.snapshot()
+	for _, fn := range f.observers {
+		fn(c)
+	}
+}
+
+// contentOf lcd if it keeps track of it.
+func contentOf(lcd LCD) ([]string, bool) {
+	if fb, ok := FramebufferOf(lcd); ok {
+		return fb.Lines(), true
+	}
+	return nil, false
+}
+
+// restore the content of lcd as returned by contentOf.
+func restore(lcd LCD, lines []string) error {
+	for i, txt := range lines {
+		if err := lcd.Write(Line(i), txt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
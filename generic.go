@@ -1,9 +1,19 @@
 package display
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"github.com/artvel/display/asustorproto"
+	"github.com/artvel/display/qnapproto"
+	"github.com/artvel/display/text"
 	"log"
+	"os"
+	"runtime/debug"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 type (
@@ -12,19 +22,108 @@ type (
 		Open() error
 		// Write a string message on line one or two.
 		// If text is longer than supported, it will be cut.
+		// Lines the display doesn't have fail with ErrUnsupportedLine.
+		// The control codes of text.Expand align the text or insert
+		// glyphs.
+		// Writes while the display is disabled are shown once it is
+		// enabled, see WithDisabledPolicy.
 		Write(line Line, text string) error
 		// Enable(turn on) or disable(turn off) the display.
 		Enable(yes bool) error
-		// Listen blocking for button events.
-		// Please note, not all devices support released=true.
+		// Listen blocking for button events. Every button is
+		// reported pressed and released, displays reporting only one
+		// of them synthesize the other.
+		// It returns once l returns false, StopListening is called
+		// or the display is closed, and can be called again later.
 		Listen(l func(btn int, released bool) bool)
 		// Close the connection to the display.
 		Close() error
 	}
+	// Flusher is implemented by displays which pace their writes.
+	// Flush blocks until all pending writes were processed,
+	// use it for example before calling Close.
+	Flusher interface {
+		Flush() error
+	}
+	// ButtonInjector is implemented by displays which accept
+	// synthesized button events, for example from tests or remote UIs.
+	// The events are delivered to Listen like hardware events.
+	ButtonInjector interface {
+		InjectButton(btn int, released bool) error
+	}
+	// ErrorReporter is implemented by displays with background
+	// goroutines. If they die, for example because the serial port
+	// vanished, the cause is sent on the channel so applications can
+	// reconnect or alert. Errors are dropped if nobody receives them.
+	ErrorReporter interface {
+		Errors() <-chan error
+	}
+	// WidthReporter is implemented by the drivers, Width is the number
+	// of characters of a line.
+	WidthReporter interface {
+		Width() int
+	}
+	// PortReporter is implemented by the drivers, Port is the serial
+	// port the display was found on.
+	PortReporter interface {
+		Port() string
+	}
+	// Wrapper is implemented by displays decorating another one,
+	// the helpers of the package look through them.
+	Wrapper interface {
+		Unwrap() LCD
+	}
+	// DeviceInfo describes the display found by FindStrict.
+	DeviceInfo struct {
+		// Driver is Asustor or Qnap.
+		Driver string
+		TTY    string
+		// Quirks applied for the model.
+		Quirks Quirks
+	}
+	// PanicError is a panic of the serial backend recovered by a
+	// driver. It is returned by the failed operation or, for the
+	// background reader, sent on Errors.
+	PanicError struct {
+		// Op is open or read.
+		Op    string
+		Value interface{}
+		Stack []byte
+	}
+	// ProbeError is returned if no display was found.
+	ProbeError struct {
+		// Causes by driver name, followed by the port if several
+		// ports were probed.
+		Causes map[string]error
+	}
 	// The line on the display. Most of them support only 0 and 1.
 	Line int
 	// Placeholder for an actual implementation
 	dummy struct{}
+	// driver is a display implementation Find probes
+	driver struct {
+		name   string
+		open   func(tty string, opts ...Option) (LCD, error)
+		serial func(tty string) SerialConfig
+		parser func() frameParser
+	}
+	// probeResult of the drivers on a port
+	probeResult struct {
+		lcd    LCD
+		info   DeviceInfo
+		causes map[string]error
+	}
+	// frameParser splits the bytes read into the frames of a protocol
+	frameParser interface {
+		Feed(data []byte) [][]byte
+	}
+	// unavailable is returned by Find without the dummy fallback
+	unavailable struct {
+		m    sync.Mutex
+		err  error
+		opts []Option
+		lcd  LCD
+	}
 )
 
 var (
@@ -32,40 +131,215 @@ var (
 	ErrClosed            = errors.New("display closed")
 	ErrDisplayNotWorking = errors.New("display not working")
 	ErrMsgSizeMismatch   = errors.New("msg size mismatch")
+	ErrNotSupported      = errors.New("not supported by display")
+	ErrUnsupportedLine   = errors.New("line not supported by display")
+	ErrPortBusy          = errors.New("serial port in use by another process")
+	ErrWriteTimeout      = errors.New("display write timed out")
 
-	filledSquare = string([]byte{0xff})
+	filledSquare = text.Filled
 )
 
+// drivers in the order they are probed
+var drivers = []driver{
+	{"Asustor", NewAsustorLCD, asustorSerial, func() frameParser { return asustorproto.NewParser() }},
+	{"Qnap", NewQnapLCD, qnapSerial, func() frameParser { return qnapproto.NewParser() }},
+}
+
 const (
-	LineOne    Line = 0
-	LineTwo    Line = 1
-	DefaultTTy      = "/dev/ttyS1"
-	c16             = 16
+	LineOne Line = 0
+	LineTwo Line = 1
+	c16          = 16
+	// errors are kept until received up to this amount
+	errBufferSize = 10
 )
 
-// Factory function to probe the correct implementation
-func Find() LCD {
-	var (
-		lcd LCD
-		err error
-	)
-	lcd, err = NewAsustorLCD("")
+// Factory function to probe the correct implementation.
+// The options are passed to the drivers.
+func Find(opts ...Option) LCD {
+	lcd, _, err := probe(context.Background(), opts)
 	if err == nil {
-		log.Println("Using Asustor LCD")
 		return lcd
-	} else {
-		lcd, err = NewQnapLCD("")
-		if err == nil {
-			log.Println("Using Qnap LCD")
-			return lcd
-		} else {
-			log.Println(err)
-		}
+	}
+	log.Println(err)
+	if newOptions(opts).noDummy {
+		return &unavailable{err: err, opts: opts}
 	}
 	log.Println("Using Dummy LCD")
 	return DummyLCD
 }
 
+// WithoutDummyFallback makes Find return a display failing every
+// operation with the ProbeError instead of the DummyLCD, for devices
+// where a missing panel is a defect. Open probes again.
+func WithoutDummyFallback() Option {
+	return func(o *options) {
+		o.noDummy = true
+	}
+}
+
+// FindAsync returns right away with a Proxy of the DummyLCD and keeps
+// probing in the background with a growing pause until a display is
+// found or ctx is done. The proxy then switches to the display and it
+// is sent on the channel, for example to log it.
+func FindAsync(ctx context.Context, opts ...Option) (*Proxy, <-chan LCD) {
+	p := NewProxy(DummyLCD)
+	found := make(chan LCD, 1)
+	c := newOptions(opts).clock
+	go func() {
+		defer close(found)
+		backoff := time.Second
+		for {
+			if lcd, _, err := probe(ctx, opts); err == nil {
+				_ = p.Swap(lcd)
+				found <- lcd
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.After(backoff):
+			}
+			if backoff *= 2; backoff > time.Minute {
+				backoff = time.Minute
+			}
+		}
+	}()
+	return p, found
+}
+
+// FindStrict probes the supported displays like Find but returns a
+// ProbeError with the cause per driver instead of the DummyLCD.
+func FindStrict(opts ...Option) (LCD, DeviceInfo, error) {
+	return probe(context.Background(), opts)
+}
+
+// FindContext is FindStrict giving up once ctx is done, for example to
+// bound the startup of a service. It returns the error of ctx then.
+// The ports are probed concurrently, the drivers per port one after
+// the other, and the first display found wins.
+func FindContext(ctx context.Context, opts ...Option) (LCD, DeviceInfo, error) {
+	return probe(ctx, opts)
+}
+
+// probe the supported displays on the configured tty. Without one it
+// is the port of a known model, DefaultTTy or every port on platforms
+// where the port varies.
+func probe(ctx context.Context, opts []Option) (LCD, DeviceInfo, error) {
+	opts = withEnv(opts)
+	driver := os.Getenv(EnvDriver)
+	if strings.EqualFold(driver, "dummy") {
+		return DummyLCD, DeviceInfo{Driver: "Dummy"}, nil
+	}
+	ttys := []string{newOptions(opts).tty}
+	var quirks Quirks
+	if model, ok := DetectModel(); ok && driver == "" {
+		driver = model.Driver
+		if ttys[0] == "" {
+			ttys[0] = model.TTY
+		}
+		// explicit options win over the quirks
+		quirks = model.Quirks
+		opts = append([]Option{WithQuirks(quirks)}, opts...)
+	}
+	if ttys[0] == "" {
+		ttys[0] = DefaultTTy
+		if ports, err := Ports(); scanPorts && err == nil && len(ports) > 0 {
+			ttys = ports
+		}
+	}
+
+	o := newOptions(opts)
+	dopts := opts
+	if len(ttys) > 1 || !strings.EqualFold(driver, "Asustor") {
+		// only scan the ports of a known ASUSTOR, the frames could
+		// confuse other devices
+		dopts = append(dopts[:len(dopts):len(dopts)], withoutPortScan())
+	}
+	results := make(chan probeResult, len(ttys))
+	for _, tty := range ttys {
+		go func(tty string) {
+			res := probeResult{causes: map[string]error{}}
+			for _, d := range o.probeOrder(tty) {
+				if driver != "" && !strings.EqualFold(driver, d.name) {
+					continue
+				}
+				if ctx.Err() != nil {
+					break
+				}
+				name := d.name
+				if len(ttys) > 1 {
+					name += " " + tty
+				}
+				lcd, err := d.open(tty, dopts...)
+				if err == nil {
+					res.lcd, res.info = lcd, DeviceInfo{Driver: d.name, TTY: tty, Quirks: quirks}
+					if port := PortOf(lcd); port != "" {
+						res.info.TTY = port
+					}
+					break
+				}
+				res.causes[name] = err
+			}
+			results <- res
+		}(tty)
+	}
+
+	perr := &ProbeError{Causes: map[string]error{}}
+	for pending := len(ttys); pending > 0; pending-- {
+		select {
+		case res := <-results:
+			if res.lcd != nil {
+				go closeLate(results, pending-1)
+				log.Printf("Using %s LCD on %s", res.info.Driver, res.info.TTY)
+				return res.lcd, res.info, nil
+			}
+			for name, err := range res.causes {
+				perr.Causes[name] = err
+			}
+		case <-ctx.Done():
+			go closeLate(results, pending)
+			return nil, DeviceInfo{}, ctx.Err()
+		}
+	}
+	if len(perr.Causes) == 0 {
+		perr.Causes[driver] = errUnknownDriver
+	}
+	return nil, DeviceInfo{}, perr
+}
+
+// closeLate the displays found after the probe returned.
+func closeLate(results <-chan probeResult, n int) {
+	for ; n > 0; n-- {
+		if res := <-results; res.lcd != nil {
+			_ = res.lcd.Close()
+		}
+	}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("display panic in %s: %v", e.Op, e.Value)
+}
+
+// recovered turns a panic into a PanicError in err, use it deferred.
+func recovered(op string, err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Op: op, Value: r, Stack: debug.Stack()}
+	}
+}
+
+func (e *ProbeError) Error() string {
+	names := make([]string, 0, len(e.Causes))
+	for name := range e.Causes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = name + ": " + e.Causes[name].Error()
+	}
+	return "no display found (" + strings.Join(msgs, ", ") + ")"
+}
+
 /*
  Dummy functions to use as an actual display.
  As the display is mostly a nice to have feature anyways.
@@ -74,23 +348,163 @@ func (d *dummy) Open() error                                { return nil }
 func (d *dummy) Write(line Line, text string) error         { return nil }
 func (d *dummy) Enable(yes bool) error                      { return nil }
 func (d *dummy) Listen(l func(btn int, released bool) bool) {}
+func (d *dummy) ListenEvents(l func(ev ButtonEvent) bool)   {}
+func (d *dummy) Flush() error                               { return nil }
+func (d *dummy) Errors() <-chan error                       { return nil }
 func (d *dummy) Close() error                               { return nil }
 
-func prepareTxt(txt string) string {
-	l := len(txt)
-	if l > c16 {
-		txt = txt[0:c16]
-	} else if l < c16 {
-		txt += strings.Repeat(" ", c16-l)
+func (u *unavailable) Open() error {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	if u.lcd != nil {
+		return u.lcd.Open()
+	}
+	lcd, _, err := probe(context.Background(), u.opts)
+	if err != nil {
+		u.err = err
+		return err
+	}
+	u.lcd = lcd
+	return nil
+}
+
+// current display if Open found one.
+func (u *unavailable) current() (LCD, error) {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	if u.lcd == nil {
+		return nil, u.err
 	}
-	return txt
+	return u.lcd, nil
 }
 
+func (u *unavailable) Write(line Line, text string) error {
+	lcd, err := u.current()
+	if err != nil {
+		return err
+	}
+	return lcd.Write(line, text)
+}
+
+func (u *unavailable) Enable(yes bool) error {
+	lcd, err := u.current()
+	if err != nil {
+		return err
+	}
+	return lcd.Enable(yes)
+}
+
+func (u *unavailable) Listen(l func(btn int, released bool) bool) {
+	if lcd, err := u.current(); err == nil {
+		lcd.Listen(l)
+	}
+}
+
+func (u *unavailable) Close() error {
+	if lcd, err := u.current(); err == nil {
+		return lcd.Close()
+	}
+	return nil
+}
+
+func (u *unavailable) Unwrap() LCD {
+	lcd, _ := u.current()
+	return lcd
+}
+
+// InjectButton into lcd or any display it wraps.
+func InjectButton(lcd LCD, btn int, released bool) error {
+	for _, l := range chain(lcd) {
+		if inj, ok := l.(ButtonInjector); ok {
+			return inj.InjectButton(btn, released)
+		}
+	}
+	return ErrNotSupported
+}
+
+// PortOf returns the serial port of lcd or any display it wraps,
+// empty if it isn't connected to one.
+func PortOf(lcd LCD) string {
+	for _, l := range chain(lcd) {
+		if p, ok := l.(PortReporter); ok {
+			return p.Port()
+		}
+	}
+	return ""
+}
+
+// withoutPortScan stops NewAsustorLCD from trying other ports.
+func withoutPortScan() Option {
+	return func(o *options) {
+		o.noPortScan = true
+	}
+}
+
+// chain returns lcd and all displays it wraps, outermost first.
+func chain(lcd LCD) []LCD {
+	var res []LCD
+	for lcd != nil {
+		res = append(res, lcd)
+		w, ok := lcd.(Wrapper)
+		if !ok {
+			break
+		}
+		lcd = w.Unwrap()
+	}
+	return res
+}
+
+// reportErr without blocking the background goroutine.
+func reportErr(c chan error, err error) {
+	select {
+	case c <- err:
+	default:
+	}
+}
+
+func prepareTxt(txt string) string {
+	return fit(txt, c16)
+}
+
+// fit is prepareTxt for drivers with a different width.
+func fit(txt string, width int) string {
+	return text.Expand(txt, width, CurrentTheme().Pad)
+}
+
+// Progress is a bar over a line of 16 characters filled by perc
+// percent. ProgressOn fits the bar to the display.
 func Progress(perc int) string {
-	chars := percentOf(c16, 100, perc)
-	return strings.Repeat(filledSquare, chars) + strings.Repeat("-", c16-chars)
+	return progress(perc, c16)
 }
 
-func percentOf(maxVal, maxPercent, currentPercent int) int {
-	return (maxVal * currentPercent) / maxPercent
+// ProgressOn writes a bar over the whole line filled by perc percent.
+func ProgressOn(lcd LCD, line Line, perc int) error {
+	return lcd.Write(line, progress(perc, WidthOf(lcd)))
+}
+
+// progress bar in the current theme.
+func progress(perc, width int) string {
+	t := CurrentTheme()
+	return text.ProgressWith(perc, width, t.Filled, t.Empty)
+}
+
+// WidthOf the lines of lcd or any display it wraps, 16 if none of them
+// reports it.
+func WidthOf(lcd LCD) int {
+	for _, l := range chain(lcd) {
+		if w, ok := l.(WidthReporter); ok {
+			return w.Width()
+		}
+	}
+	return c16
+}
+
+// LineCountOf lcd, two if it doesn't keep track of its lines.
+func LineCountOf(lcd LCD) int {
+	if fb, ok := FramebufferOf(lcd); ok {
+		return len(fb.Lines())
+	}
+	return 2
 }
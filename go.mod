@@ -4,5 +4,5 @@ go 1.16
 
 require (
 	github.com/chmorgan/go-serial2 v0.0.0-20190806182038-472d60f85d9b
-	golang.org/x/sys v0.0.0-20210603125802-9665404d3644 // indirect
+	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
 )
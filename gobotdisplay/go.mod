@@ -0,0 +1,10 @@
+module github.com/artvel/display/gobotdisplay
+
+go 1.16
+
+require (
+	github.com/artvel/display v0.0.0
+	gobot.io/x/gobot v1.16.0
+)
+
+replace github.com/artvel/display => ../
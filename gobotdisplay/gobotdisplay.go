@@ -0,0 +1,170 @@
+// Package gobotdisplay adapts the displays to gobot.io, so NAS panels
+// can be part of gobot robots. The buttons are published as events.
+//
+//	a := gobotdisplay.NewAdaptor(display.Find())
+//	d := gobotdisplay.NewDriver(a)
+//	work := func() {
+//		_ = d.Write(display.LineOne, "Hello gobot")
+//		_ = d.On(gobotdisplay.Release, func(data interface{}) {
+//			ev := data.(display.ButtonEvent)
+//			_ = d.Write(display.LineTwo, ev.Button.String())
+//		})
+//	}
+//	robot := gobot.NewRobot("nas", []gobot.Connection{a}, []gobot.Device{d}, work)
+//	_ = robot.Start()
+//
+// It is a module of its own to keep gobot out of the dependencies of
+// the display package.
+package gobotdisplay
+
+import (
+	"context"
+	"github.com/artvel/display"
+	"gobot.io/x/gobot"
+	"sync"
+)
+
+type (
+	// Adaptor is the gobot connection to a display.
+	Adaptor struct {
+		name string
+		lcd  display.LCD
+	}
+
+	// Driver writes to the display of its Adaptor and publishes the
+	// button events as Push and Release with the display.ButtonEvent
+	// as data. It also has the commands write with the params line and
+	// text, and enable with on.
+	Driver struct {
+		gobot.Eventer
+		gobot.Commander
+		name       string
+		connection *Adaptor
+
+		m      sync.Mutex
+		cancel context.CancelFunc
+		done   chan struct{}
+	}
+)
+
+// Events published by the Driver
+const (
+	Push    = "push"
+	Release = "release"
+)
+
+// NewAdaptor for lcd, which is opened by Connect and closed by Finalize.
+func NewAdaptor(lcd display.LCD) *Adaptor {
+	return &Adaptor{name: gobot.DefaultName("Display"), lcd: lcd}
+}
+
+func (a *Adaptor) Name() string {
+	return a.name
+}
+
+func (a *Adaptor) SetName(n string) {
+	a.name = n
+}
+
+// Connect opens the display, open displays stay as they are.
+func (a *Adaptor) Connect() error {
+	return a.lcd.Open()
+}
+
+// Finalize closes the display.
+func (a *Adaptor) Finalize() error {
+	return a.lcd.Close()
+}
+
+// LCD of the adaptor.
+func (a *Adaptor) LCD() display.LCD {
+	return a.lcd
+}
+
+// NewDriver for the display of a.
+func NewDriver(a *Adaptor) *Driver {
+	d := &Driver{
+		Eventer:    gobot.NewEventer(),
+		Commander:  gobot.NewCommander(),
+		name:       gobot.DefaultName("Display"),
+		connection: a,
+	}
+	d.AddEvent(Push)
+	d.AddEvent(Release)
+	d.AddCommand("write", func(params map[string]interface{}) interface{} {
+		text, _ := params["text"].(string)
+		// numbers of the API are float64
+		switch line := params["line"].(type) {
+		case float64:
+			return d.Write(display.Line(line), text)
+		case int:
+			return d.Write(display.Line(line), text)
+		}
+		return display.ErrUnsupportedLine
+	})
+	d.AddCommand("enable", func(params map[string]interface{}) interface{} {
+		on, _ := params["on"].(bool)
+		return d.Enable(on)
+	})
+	return d
+}
+
+func (d *Driver) Name() string {
+	return d.name
+}
+
+func (d *Driver) SetName(n string) {
+	d.name = n
+}
+
+func (d *Driver) Connection() gobot.Connection {
+	return d.connection
+}
+
+// Start publishing the button events.
+func (d *Driver) Start() error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.cancel != nil {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel, d.done = cancel, make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		display.ListenEventsContext(ctx, d.connection.lcd, func(ev display.ButtonEvent) bool {
+			if ev.Released {
+				d.Publish(Release, ev)
+			} else {
+				d.Publish(Push, ev)
+			}
+			return true
+		})
+	}(d.done)
+	return nil
+}
+
+// Halt stops publishing the button events.
+func (d *Driver) Halt() error {
+	d.m.Lock()
+	cancel, done := d.cancel, d.done
+	d.cancel = nil
+	d.m.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	return nil
+}
+
+// Write text on line.
+func (d *Driver) Write(line display.Line, text string) error {
+	return d.connection.lcd.Write(line, text)
+}
+
+// Enable turns the display on or off.
+func (d *Driver) Enable(on bool) error {
+	return d.connection.lcd.Enable(on)
+}
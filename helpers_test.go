@@ -0,0 +1,196 @@
+package display
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLCD records the writes of the helpers, it has the lines of its
+// framebuffer and width characters. The listeners get the events sent
+// to events until it is closed. The wrappers use clock if it is set.
+type fakeLCD struct {
+	m      sync.Mutex
+	fb     *Framebuffer
+	width  int
+	writes []string
+	events chan ButtonEvent
+	clock  Clock
+}
+
+func newFakeLCD(lines, width int) *fakeLCD {
+	return &fakeLCD{fb: NewFramebuffer(lines), width: width, events: make(chan ButtonEvent, 16)}
+}
+
+func (f *fakeLCD) Open() error  { return nil }
+func (f *fakeLCD) Close() error { return nil }
+
+func (f *fakeLCD) Write(line Line, text string) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if _, err := f.fb.Line(line); err != nil {
+		return err
+	}
+	f.writes = append(f.writes, text)
+	f.fb.Set(line, fit(text, f.width))
+	return nil
+}
+
+func (f *fakeLCD) Enable(yes bool) error {
+	f.fb.SetEnabled(yes)
+	return nil
+}
+
+func (f *fakeLCD) Listen(l func(btn int, released bool) bool) {}
+
+func (f *fakeLCD) ListenEventsContext(ctx context.Context, l func(ev ButtonEvent) bool) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-f.events:
+			if !ok || !l(ev) {
+				return
+			}
+		}
+	}
+}
+
+func (f *fakeLCD) Framebuffer() *Framebuffer {
+	return f.fb
+}
+
+func (f *fakeLCD) Width() int {
+	return f.width
+}
+
+func (f *fakeLCD) timeSource() Clock {
+	if f.clock == nil {
+		return realClock{}
+	}
+	return f.clock
+}
+
+// written returns the texts written so far.
+func (f *fakeLCD) written() []string {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return append([]string(nil), f.writes...)
+}
+
+// fakeClock only moves when advanced, Sleep advances it right away.
+type fakeClock struct {
+	m      sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	c  *fakeClock
+	at time.Time
+	f  func()
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.AfterFunc(d, func() { ch <- c.Now() })
+	return ch
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	t := &fakeTimer{c: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance the time by d and fire the timers due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.m.Lock()
+	c.now = c.now.Add(d)
+	var due []*fakeTimer
+	timers := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			timers = append(timers, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	c.timers = timers
+	c.m.Unlock()
+
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// waiting blocks until n timers are pending, so a goroutine about to
+// wait doesn't miss an Advance.
+func (c *fakeClock) waiting(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.m.Lock()
+		pending := len(c.timers)
+		c.m.Unlock()
+		if pending >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d timers pending, want %d", pending, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.c.m.Lock()
+	defer t.c.m.Unlock()
+
+	for i, p := range t.c.timers {
+		if p == t {
+			t.c.timers = append(t.c.timers[:i], t.c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// noLeaks fails t if goroutines started after it are still running once
+// the returned function is called, defer it at the start of a test.
+func noLeaks(t *testing.T) func() {
+	before := runtime.NumGoroutine()
+	return func() {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before {
+			if time.Now().After(deadline) {
+				buf := make([]byte, 1<<16)
+				t.Fatalf("%d goroutines leaked:\n%s", runtime.NumGoroutine()-before, buf[:runtime.Stack(buf, true)])
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
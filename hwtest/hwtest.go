@@ -0,0 +1,103 @@
+//go:build hardware
+// +build hardware
+
+// Package hwtest validates an attached panel end to end, for driver
+// contributors and before a release. It is only built with the
+// hardware tag, run it with
+//
+//	go run -tags hardware ./cmd/displaytest -tty /dev/ttyS1
+package hwtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/artvel/display"
+	"strings"
+	"time"
+)
+
+// Options of Run.
+type Options struct {
+	// ButtonTimeout the operator has to press a button, zero skips
+	// the button step.
+	ButtonTimeout time.Duration
+}
+
+// ErrNoButton is the result of the button step if nothing was pressed.
+var ErrNoButton = errors.New("no button pressed in time")
+
+// Run writes both lines and reads them back, waits for the panel to
+// process the writes, cycles the display off and on and asks the
+// operator to press a button. Every step is run, the first failure
+// doesn't stop the suite.
+func Run(lcd display.LCD, o Options) display.SelfTestReport {
+	var report display.SelfTestReport
+	step := func(name string, fn func() error) {
+		report = append(report, display.SelfTestStep{Name: name, Err: fn()})
+	}
+
+	step("write", func() error {
+		want := []string{"hwtest line 1", "hwtest line 2"}
+		for i, txt := range want {
+			if err := lcd.Write(display.Line(i), txt); err != nil {
+				return err
+			}
+		}
+		got, err := display.Snapshot(lcd)
+		if err == display.ErrNotSupported {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		for i, txt := range want {
+			if i >= len(got) || strings.TrimRight(got[i], " ") != txt {
+				return fmt.Errorf("line %d shows %q, want %q", i+1, got, txt)
+			}
+		}
+		return nil
+	})
+	step("ack", func() error {
+		if f, ok := lcd.(display.Flusher); ok {
+			return f.Flush()
+		}
+		return nil
+	})
+	step("enable cycle", func() error {
+		for _, yes := range []bool{false, true, false, true} {
+			if err := lcd.Enable(yes); err != nil {
+				return fmt.Errorf("enable %v: %w", yes, err)
+			}
+			time.Sleep(300 * time.Millisecond)
+		}
+		return nil
+	})
+	if o.ButtonTimeout > 0 {
+		step("button", func() error {
+			if err := lcd.Write(display.LineOne, "Press a button"); err != nil {
+				return err
+			}
+			_ = lcd.Write(display.LineTwo, fmt.Sprintf("within %v", o.ButtonTimeout))
+			ctx, cancel := context.WithTimeout(context.Background(), o.ButtonTimeout)
+			defer cancel()
+			pressed := false
+			display.ListenEventsContext(ctx, lcd, func(ev display.ButtonEvent) bool {
+				pressed = true
+				return false
+			})
+			if !pressed {
+				return ErrNoButton
+			}
+			return nil
+		})
+	}
+	step("clear", func() error {
+		for _, line := range []display.Line{display.LineOne, display.LineTwo} {
+			if err := lcd.Write(line, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return report
+}
@@ -0,0 +1,192 @@
+package display
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type (
+	// NumberInput lets the user pick a number with up and down.
+	// It is accepted with select or by holding a button for LongPress,
+	// which makes it usable on panels with two buttons only.
+	NumberInput struct {
+		Label string
+		Min   int
+		Max   int
+		// Step defaults to 1.
+		Step  int
+		Value int
+		// LongPress defaults to DefaultLongPress.
+		LongPress time.Duration
+		// Keymap defaults to the DefaultKeymap of the display.
+		Keymap Keymap
+	}
+
+	// TextInput lets the user enter a short string character by character.
+	// Up and down change the character under the cursor, select or a long
+	// press moves on to the next one. Picking the end marker accepts the
+	// text, back deletes the last character.
+	TextInput struct {
+		Label string
+		// Charset to pick from, defaults to DefaultCharset.
+		Charset string
+		// MaxLen defaults to the width of the display.
+		MaxLen int
+		Value  string
+		// LongPress defaults to DefaultLongPress.
+		LongPress time.Duration
+		// Keymap defaults to the DefaultKeymap of the display.
+		Keymap Keymap
+	}
+)
+
+const (
+	// DefaultLongPress is the hold duration accepting an input.
+	DefaultLongPress = time.Second
+	// DefaultCharset of the TextInput.
+	DefaultCharset = "0123456789.abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-_ "
+	// IPCharset is enough to enter an IPv4 address.
+	IPCharset = "0123456789."
+	// inputEnd is shown as right arrow on HD44780 compatible panels
+	inputEnd = '\x7e'
+)
+
+var ErrCanceled = errors.New("input canceled")
+
+// Run shows the input on lcd and blocks until the value was
+// accepted or canceled with back.
+func (n *NumberInput) Run(lcd LCD) (int, error) {
+	step := n.Step
+	if step <= 0 {
+		step = 1
+	}
+	value := n.Value
+	width := WidthOf(lcd)
+	render := func() error {
+		if err := lcd.Write(LineOne, n.Label); err != nil {
+			return err
+		}
+		return lcd.Write(LineTwo, center("< "+strconv.Itoa(value)+" >", width))
+	}
+	if err := render(); err != nil {
+		return value, err
+	}
+	var err error
+	done := false
+	ListenKeys(lcd, n.Keymap, func(key Key, ev ButtonEvent) bool {
+		switch {
+		case key == KeySelect || isLongPress(ev, n.LongPress):
+			done = true
+			return false
+		case key == KeyBack:
+			err, done = ErrCanceled, true
+			return false
+		case key == KeyUp:
+			value += step
+			if value > n.Max {
+				value = n.Min
+			}
+		case key == KeyDown:
+			value -= step
+			if value < n.Min {
+				value = n.Max
+			}
+		}
+		err = render()
+		return err == nil
+	})
+	if err == nil && !done {
+		err = ErrClosed
+	}
+	if err == nil {
+		n.Value = value
+	}
+	return value, err
+}
+
+// Run shows the input on lcd and blocks until the text was
+// accepted or canceled with back on the first character.
+func (t *TextInput) Run(lcd LCD) (string, error) {
+	charset := []rune(t.Charset)
+	if len(charset) == 0 {
+		charset = []rune(DefaultCharset)
+	}
+	charset = append(charset, inputEnd)
+	width := WidthOf(lcd)
+	maxLen := t.MaxLen
+	if maxLen <= 0 {
+		maxLen = width
+	}
+	value := []rune(t.Value)
+	cursor := 0
+	render := func() error {
+		if err := lcd.Write(LineOne, t.Label); err != nil {
+			return err
+		}
+		// keep the cursor in view, cut in runes
+		txt := append(append([]rune(nil), value...), '[', charset[cursor], ']')
+		if l := len(txt); l > width {
+			txt = txt[l-width:]
+		}
+		return lcd.Write(LineTwo, string(txt))
+	}
+	if err := render(); err != nil {
+		return string(value), err
+	}
+	var err error
+	done := false
+	ListenKeys(lcd, t.Keymap, func(key Key, ev ButtonEvent) bool {
+		switch {
+		case key == KeySelect || isLongPress(ev, t.LongPress):
+			if charset[cursor] == inputEnd {
+				done = true
+				return false
+			}
+			value = append(value, charset[cursor])
+			if len(value) >= maxLen {
+				done = true
+				return false
+			}
+		case key == KeyBack:
+			if len(value) == 0 {
+				err, done = ErrCanceled, true
+				return false
+			}
+			value = value[:len(value)-1]
+		case key == KeyUp:
+			cursor = (cursor + 1) % len(charset)
+		case key == KeyDown:
+			cursor = (cursor + len(charset) - 1) % len(charset)
+		}
+		err = render()
+		return err == nil
+	})
+	if err == nil && !done {
+		err = ErrClosed
+	}
+	if err == nil {
+		t.Value = string(value)
+	}
+	return string(value), err
+}
+
+// isLongPress reports if the button was released after d, the repeats
+// while it is held don't count.
+func isLongPress(ev ButtonEvent, d time.Duration) bool {
+	if d <= 0 {
+		d = DefaultLongPress
+	}
+	return !ev.Repeat && ev.Held >= d
+}
+
+// center txt within width characters.
+func center(txt string, width int) string {
+	l := utf8.RuneCountInString(txt)
+	if l >= width {
+		return txt
+	}
+	return strings.Repeat(" ", (width-l)/2) + txt
+}
@@ -0,0 +1,44 @@
+package display
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTextInputUsesTheWidthOfTheDisplay(t *testing.T) {
+	lcd := newFakeLCD(2, 8)
+	lcd.events <- ButtonEvent{Button: ButtonEnter, Released: true}
+	close(lcd.events)
+	in := TextInput{Label: "name", Charset: "x", Value: "äöüäöüä"}
+	got, err := in.Run(lcd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the width limits the length of the text
+	if got != "äöüäöüäx" {
+		t.Errorf("got %q", got)
+	}
+	// the end of the text is shown, cut in runes
+	if w := lcd.written(); w[1] != "üäöüä[x]" {
+		t.Errorf("showed %q", w[1])
+	}
+}
+
+func TestNumberInputCentersOnTheWidthOfTheDisplay(t *testing.T) {
+	lcd := newFakeLCD(2, 20)
+	close(lcd.events)
+	in := NumberInput{Label: "count", Min: 0, Max: 9, Value: 5}
+	_, _ = in.Run(lcd)
+	if w := lcd.written(); w[1] != "       < 5 >" {
+		t.Errorf("showed %q", w[1])
+	}
+}
+
+func TestLongPressIgnoresRepeats(t *testing.T) {
+	if isLongPress(ButtonEvent{Held: 2 * time.Second, Repeat: true}, time.Second) {
+		t.Error("repeat counted as long press")
+	}
+	if !isLongPress(ButtonEvent{Held: 2 * time.Second, Released: true}, time.Second) {
+		t.Error("release after a second isn't a long press")
+	}
+}
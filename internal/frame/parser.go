@@ -0,0 +1,50 @@
+// Package frame splits byte streams of the display protocols into frames.
+package frame
+
+// Parser splits the byte stream read from a display into frames.
+// It keeps partial frames between reads, skips garbage in front
+// of a frame and resyncs on the next start byte if a complete
+// frame turns out to be invalid.
+type Parser struct {
+	// Start reports if b can be the first byte of a frame.
+	Start func(b byte) bool
+	// Size returns the size of the frame at the beginning of buf
+	// or 0 if more bytes are needed to know it.
+	Size func(buf []byte) int
+	// Valid reports if a complete frame is intact.
+	Valid func(frame []byte) bool
+
+	buf []byte
+}
+
+// Feed appends the bytes of a read and returns all complete frames.
+func (p *Parser) Feed(data []byte) (frames [][]byte) {
+	p.buf = append(p.buf, data...)
+	for {
+		i := 0
+		for i < len(p.buf) && !p.Start(p.buf[i]) {
+			i++
+		}
+		p.buf = p.buf[i:]
+		if len(p.buf) == 0 {
+			p.buf = nil
+			return
+		}
+		n := p.Size(p.buf)
+		if n <= 0 || len(p.buf) < n {
+			return
+		}
+		if !p.Valid(p.buf[:n]) {
+			// the start byte was part of something else
+			p.buf = p.buf[1:]
+			continue
+		}
+		frames = append(frames, append([]byte(nil), p.buf[:n]...))
+		p.buf = p.buf[n:]
+	}
+}
+
+// Reset drops a partial frame.
+func (p *Parser) Reset() {
+	p.buf = nil
+}
@@ -0,0 +1,19 @@
+//go:build go1.18
+// +build go1.18
+
+package frame
+
+import "testing"
+
+func FuzzParse(f *testing.F) {
+	f.Add(encode(1, 2))
+	f.Add(append([]byte{0xAA, 0xAA, 9}, encode(3)...))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p := newParser()
+		for _, fr := range p.Feed(data) {
+			if fr[0] != 0xAA || len(fr) != int(fr[1])+3 || fr[len(fr)-1] != sum(fr[1:len(fr)-1]) {
+				t.Fatalf("invalid frame %x", fr)
+			}
+		}
+	})
+}
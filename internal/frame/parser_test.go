@@ -0,0 +1,87 @@
+package frame
+
+import (
+	"bytes"
+	"testing"
+)
+
+// newParser of a toy protocol: 0xAA LENGTH DATA... SUM, the sum is
+// the low byte of the sum of the length and the data.
+func newParser() *Parser {
+	return &Parser{
+		Start: func(b byte) bool { return b == 0xAA },
+		Size: func(buf []byte) int {
+			if len(buf) < 2 {
+				return 0
+			}
+			return int(buf[1]) + 3
+		},
+		Valid: func(f []byte) bool {
+			return f[len(f)-1] == sum(f[1:len(f)-1])
+		},
+	}
+}
+
+func sum(b []byte) byte {
+	var s byte
+	for _, c := range b {
+		s += c
+	}
+	return s
+}
+
+func encode(data ...byte) []byte {
+	f := append([]byte{0xAA, byte(len(data))}, data...)
+	return append(f, sum(f[1:]))
+}
+
+func TestFeed(t *testing.T) {
+	a, b := encode(1, 2), encode(3)
+	broken := append([]byte(nil), a...)
+	broken[len(broken)-1]++
+	cat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	tests := []struct {
+		name  string
+		reads [][]byte
+		want  [][]byte
+	}{
+		{"single frame", [][]byte{a}, [][]byte{a}},
+		{"two frames in a read", [][]byte{cat(a, b)}, [][]byte{a, b}},
+		{"wrong checksum", [][]byte{broken}, nil},
+		{"resync after wrong checksum", [][]byte{cat(broken, b)}, [][]byte{b}},
+		{"resync after garbage", [][]byte{cat([]byte{1, 2, 0xFF}, a)}, [][]byte{a}},
+		{"split read", [][]byte{a[:1], a[1:3], a[3:]}, [][]byte{a}},
+		{"split over frames", [][]byte{cat(a, b[:2]), b[2:]}, [][]byte{a, b}},
+		{"empty frame", [][]byte{encode()}, [][]byte{encode()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newParser()
+			var got [][]byte
+			for _, r := range tt.reads {
+				got = append(got, p.Feed(r)...)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %x, want %x", got, tt.want)
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], tt.want[i]) {
+					t.Errorf("frame %d is %x, want %x", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	p := newParser()
+	a := encode(1, 2)
+	p.Feed(a[:3])
+	p.Reset()
+	if got := p.Feed(a[3:]); len(got) != 0 {
+		t.Errorf("got %x after Reset", got)
+	}
+	if got := p.Feed(a); len(got) != 1 {
+		t.Errorf("got %x, want a frame", got)
+	}
+}
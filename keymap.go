@@ -0,0 +1,131 @@
+package display
+
+import (
+	"context"
+	"fmt"
+)
+
+type (
+	// Key is the logical meaning of a button, menus are written
+	// against keys to work on every panel unchanged.
+	Key int
+
+	// Keymap translates the physical buttons of a device into keys.
+	Keymap map[Button]Key
+
+	// keymapper is implemented by displays with their own default keymap.
+	keymapper interface {
+		DefaultKeymap() Keymap
+	}
+)
+
+const (
+	KeyNone Key = iota
+	KeyUp
+	KeyDown
+	KeySelect
+	KeyBack
+)
+
+var (
+	// AsustorKeymap maps the four buttons of the ASUSTOR panel.
+	AsustorKeymap = Keymap{
+		ButtonUp:    KeyUp,
+		ButtonDown:  KeyDown,
+		ButtonEnter: KeySelect,
+		ButtonBack:  KeyBack,
+	}
+	// QnapKeymap maps the two buttons of the QNAP panel,
+	// pressing both selects.
+	QnapKeymap = Keymap{
+		ButtonUp:   KeyUp,
+		ButtonDown: KeyDown,
+		ButtonBoth: KeySelect,
+	}
+	// GenericKeymap is used for displays without their own keymap.
+	GenericKeymap = Keymap{
+		ButtonUp:    KeyUp,
+		ButtonDown:  KeyDown,
+		ButtonBoth:  KeySelect,
+		ButtonEnter: KeySelect,
+		ButtonBack:  KeyBack,
+	}
+)
+
+// DefaultKeymap of the device behind lcd.
+func DefaultKeymap(lcd LCD) Keymap {
+	for _, l := range chain(lcd) {
+		if k, ok := l.(keymapper); ok {
+			return k.DefaultKeymap()
+		}
+	}
+	return GenericKeymap
+}
+
+// With returns a copy of the keymap with the overrides applied.
+// Map a button to KeyNone to disable it.
+func (k Keymap) With(overrides Keymap) Keymap {
+	res := make(Keymap, len(k)+len(overrides))
+	for b, key := range k {
+		res[b] = key
+	}
+	for b, key := range overrides {
+		res[b] = key
+	}
+	return res
+}
+
+// Key of the button or KeyNone if it isn't mapped.
+func (k Keymap) Key(b Button) Key {
+	return k[b]
+}
+
+// ListenKeys blocks and passes the keys of lcd translated by km to l,
+// until l returns false. A nil keymap means DefaultKeymap.
+// Keys are delivered on release, as it is the only event all devices
+// report and it tells both QNAP buttons apart from a single one. With
+// WithAutoRepeat a held button delivers its key on every repeat
+// instead, and not again on release.
+func ListenKeys(lcd LCD, km Keymap, l func(key Key, ev ButtonEvent) bool) {
+	ListenKeysContext(context.Background(), lcd, km, l)
+}
+
+// ListenKeysContext works like ListenKeys but returns as well once ctx is done.
+func ListenKeysContext(ctx context.Context, lcd LCD, km Keymap, l func(key Key, ev ButtonEvent) bool) {
+	if km == nil {
+		km = DefaultKeymap(lcd)
+	}
+	repeated := map[int]bool{}
+	ListenEventsContext(ctx, lcd, func(ev ButtonEvent) bool {
+		switch {
+		case ev.Repeat:
+			repeated[ev.Raw] = true
+		case !ev.Released:
+			return true
+		case repeated[ev.Raw]:
+			delete(repeated, ev.Raw)
+			return true
+		}
+		key := km.Key(ev.Button)
+		if key == KeyNone {
+			return true
+		}
+		return l(key, ev)
+	})
+}
+
+func (k Key) String() string {
+	switch k {
+	case KeyNone:
+		return "none"
+	case KeyUp:
+		return "up"
+	case KeyDown:
+		return "down"
+	case KeySelect:
+		return "select"
+	case KeyBack:
+		return "back"
+	}
+	return fmt.Sprintf("key(%d)", int(k))
+}
@@ -0,0 +1,125 @@
+package display
+
+import (
+	"strings"
+	"sync"
+)
+
+type (
+	// Layout splits the lines of a display into regions which are
+	// updated independently, like a static label on the left and a
+	// ticking value on the right. The regions are composed to lines
+	// and a line is only written if it changed.
+	Layout struct {
+		lcd     LCD
+		width   int
+		m       sync.Mutex
+		regions []*Region
+		shown   map[Line]string
+	}
+	// Region of a line, create it with Layout.Region.
+	Region struct {
+		l     *Layout
+		line  Line
+		start int
+		width int
+		align Align
+		text  string
+	}
+	// Align of the text in a region.
+	Align int
+)
+
+const (
+	AlignLeft Align = iota
+	AlignRight
+	AlignCenter
+)
+
+// NewLayout on lcd.
+func NewLayout(lcd LCD) *Layout {
+	return &Layout{lcd: lcd, width: WidthOf(lcd), shown: map[Line]string{}}
+}
+
+// Region of width cells on line starting at cell start. Cells outside
+// of the display are cut, of overlapping regions the later wins.
+func (l *Layout) Region(line Line, start, width int, align Align) *Region {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	r := &Region{l: l, line: line, start: start, width: width, align: align}
+	l.regions = append(l.regions, r)
+	return r
+}
+
+// Set the text of the region, it is cut to the width. The line is
+// written right away if it changed.
+func (r *Region) Set(text string) error {
+	r.l.m.Lock()
+	defer r.l.m.Unlock()
+
+	r.text = text
+	return r.l.draw(r.line)
+}
+
+// Redraw all lines, for example after somebody else wrote to the
+// display.
+func (l *Layout) Redraw() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.shown = map[Line]string{}
+	lines := map[Line]bool{}
+	for _, r := range l.regions {
+		if !lines[r.line] {
+			lines[r.line] = true
+			if err := l.draw(r.line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// draw line if its composition changed, must be called with the lock
+// held.
+func (l *Layout) draw(line Line) error {
+	cells := []byte(strings.Repeat(string(CurrentTheme().Pad), l.width))
+	for _, r := range l.regions {
+		if r.line != line {
+			continue
+		}
+		txt := r.aligned()
+		for i := 0; i < len(txt); i++ {
+			if c := r.start + i; c >= 0 && c < len(cells) {
+				cells[c] = txt[i]
+			}
+		}
+	}
+	txt := string(cells)
+	if shown, ok := l.shown[line]; ok && shown == txt {
+		return nil
+	}
+	if err := l.lcd.Write(line, txt); err != nil {
+		delete(l.shown, line)
+		return err
+	}
+	l.shown[line] = txt
+	return nil
+}
+
+// aligned text padded to the width.
+func (r *Region) aligned() string {
+	txt := r.text
+	if len(txt) >= r.width {
+		return txt[:r.width]
+	}
+	pad := r.width - len(txt)
+	switch r.align {
+	case AlignRight:
+		return strings.Repeat(" ", pad) + txt
+	case AlignCenter:
+		return strings.Repeat(" ", pad/2) + txt + strings.Repeat(" ", pad-pad/2)
+	}
+	return txt + strings.Repeat(" ", pad)
+}
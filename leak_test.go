@@ -0,0 +1,62 @@
+package display
+
+import (
+	"github.com/artvel/display/emulator"
+	"github.com/artvel/display/qnapproto"
+	"testing"
+	"time"
+)
+
+func TestDriversDontLeakGoroutines(t *testing.T) {
+	tests := []struct {
+		name string
+		open func() (LCD, func() error, error)
+	}{
+		{"asustor", func() (LCD, func() error, error) {
+			emu, con := emulator.NewAsustor()
+			lcd, err := NewAsustorLCDFromConn(con)
+			return lcd, func() error { return emu.Press(AsustorRawUp) }, err
+		}},
+		{"qnap", func() (LCD, func() error, error) {
+			emu, con := emulator.NewQnap()
+			lcd, err := NewQnapLCDFromConn(con)
+			return lcd, func() error { return emu.Press(qnapproto.ButtonUp) }, err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer noLeaks(t)()
+			lcd, press, err := tt.open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(chan ButtonEvent, 10)
+			listening := make(chan struct{})
+			go func() {
+				defer close(listening)
+				ListenEvents(lcd, func(ev ButtonEvent) bool {
+					got <- ev
+					return true
+				})
+			}()
+			if err = lcd.Write(LineOne, "hello"); err != nil {
+				t.Fatal(err)
+			}
+			// the listener might not listen yet
+			for received := false; !received; {
+				if err = press(); err != nil {
+					t.Fatal(err)
+				}
+				select {
+				case <-got:
+					received = true
+				case <-time.After(50 * time.Millisecond):
+				}
+			}
+			if err = lcd.Close(); err != nil {
+				t.Fatal(err)
+			}
+			<-listening
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package display
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// LEDState of a front panel LED.
+	LEDState int
+	// LEDController is implemented by displays switching the status
+	// LEDs of the front panel. The names are the ones of the device,
+	// like "asustor:green:status".
+	LEDController interface {
+		SetLED(name string, state LEDState) error
+	}
+	// sysfsLEDs switches the LEDs exported by a kernel driver.
+	sysfsLEDs struct {
+		decorator
+		root string
+	}
+)
+
+const (
+	LEDOff LEDState = iota
+	LEDOn
+	LEDBlink
+)
+
+// ledRoot holds the LEDs exported by Linux
+var ledRoot = "/sys/class/leds"
+
+// SetLED of lcd or any display it wraps. Displays without LEDs return
+// ErrNotSupported.
+func SetLED(lcd LCD, name string, state LEDState) error {
+	for _, l := range chain(lcd) {
+		if c, ok := l.(LEDController); ok {
+			return c.SetLED(name, state)
+		}
+	}
+	return ErrNotSupported
+}
+
+// HasLED reports whether lcd or any display it wraps controls LEDs.
+func HasLED(lcd LCD) bool {
+	for _, l := range chain(lcd) {
+		if _, ok := l.(LEDController); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SysfsLEDs adds the LEDs of /sys/class/leds to a display. The panels
+// have no known LED commands on the serial link, on ASUSTOR and QNAP
+// units the LEDs are exported by the kernel platform drivers
+// (asustor-platform-driver, qnap8528) instead. The name is the one of
+// the directory.
+func SysfsLEDs(lcd LCD) LCD {
+	return &sysfsLEDs{decorator: decorator{lcd}, root: ledRoot}
+}
+
+func (d *sysfsLEDs) SetLED(name string, state LEDState) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid LED name %q", name)
+	}
+	dir := filepath.Join(d.root, name)
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("LED %s: %w", name, ErrNotSupported)
+	}
+	trigger, brightness := "none", "0"
+	switch state {
+	case LEDOn:
+		brightness = "1"
+		if max, err := ioutil.ReadFile(filepath.Join(dir, "max_brightness")); err == nil {
+			brightness = strings.TrimSpace(string(max))
+		}
+	case LEDBlink:
+		trigger = "timer"
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "trigger"), []byte(trigger), 0644); err != nil {
+		return err
+	}
+	if state == LEDBlink {
+		// the timer trigger blinks at 1Hz by default
+		return nil
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "brightness"), []byte(brightness), 0644)
+}
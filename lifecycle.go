@@ -0,0 +1,156 @@
+package display
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// ConnState is the lifecycle state of a driver.
+	ConnState int
+
+	// StateReporter is implemented by drivers tracking their ConnState.
+	StateReporter interface {
+		ConnState() ConnState
+	}
+
+	// lifecycle tracks the ConnState of a driver and calls the hook
+	// on every change.
+	lifecycle struct {
+		m     sync.Mutex
+		state ConnState
+		hook  func(from, to ConnState)
+		// closing is closed by Close and replaced by Open
+		closing  chan struct{}
+		isClosed bool
+	}
+	// openFlag tells if a driver is open. It is read without the lock
+	// of the driver, so a listener doesn't wait for a write holding it.
+	openFlag int32
+	// closeNotifier is implemented by drivers, the channel is closed
+	// once Close is called.
+	closeNotifier interface {
+		closeNotify() <-chan struct{}
+	}
+)
+
+const (
+	// StateClosed drivers aren't connected, Open connects them.
+	StateClosed ConnState = iota
+	// StateOpening drivers connect and wait for the handshake.
+	StateOpening
+	// StateReady drivers work as expected.
+	StateReady
+	// StateDegraded drivers are connected but the last operation
+	// failed or the reader stopped. The next successful operation
+	// makes them ready again.
+	StateDegraded
+	// StateClosing drivers finish the last write and shut down.
+	StateClosing
+)
+
+// OnStateChange calls fn on every change of the ConnState of the driver,
+// for example to supervise and reopen it. fn is called synchronously by
+// the driver and must not call the display.
+func OnStateChange(fn func(from, to ConnState)) Option {
+	return func(o *options) {
+		o.stateHook = fn
+	}
+}
+
+// ConnStateOf lcd or any display it wraps.
+func ConnStateOf(lcd LCD) (ConnState, bool) {
+	for _, l := range chain(lcd) {
+		if r, ok := l.(StateReporter); ok {
+			return r.ConnState(), true
+		}
+	}
+	return StateClosed, false
+}
+
+func (s ConnState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpening:
+		return "opening"
+	case StateReady:
+		return "ready"
+	case StateDegraded:
+		return "degraded"
+	case StateClosing:
+		return "closing"
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+func newLifecycle(hook func(from, to ConnState)) *lifecycle {
+	return &lifecycle{hook: hook, closing: make(chan struct{})}
+}
+
+// closeNotify returns a channel closed once the driver is closed.
+func (l *lifecycle) closeNotify() <-chan struct{} {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.closing
+}
+
+func (l *lifecycle) get() ConnState {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.state
+}
+
+func (l *lifecycle) set(to ConnState) {
+	l.m.Lock()
+	from := l.state
+	l.state = to
+	switch {
+	case to == StateClosing && !l.isClosed:
+		close(l.closing)
+		l.isClosed = true
+	case to == StateOpening && l.isClosed:
+		l.closing = make(chan struct{})
+		l.isClosed = false
+	}
+	l.m.Unlock()
+
+	l.changed(from, to)
+}
+
+// result of an operation, it only changes connected drivers.
+func (l *lifecycle) result(err error) {
+	l.m.Lock()
+	from, to := l.state, l.state
+	if from == StateReady || from == StateDegraded {
+		to = StateReady
+		if err != nil {
+			to = StateDegraded
+		}
+	}
+	l.state = to
+	l.m.Unlock()
+
+	l.changed(from, to)
+}
+
+func (l *lifecycle) changed(from, to ConnState) {
+	if from != to && l.hook != nil {
+		l.hook(from, to)
+	}
+}
+
+func (f *openFlag) set(yes bool) {
+	var v int32
+	if yes {
+		v = 1
+	}
+	atomic.StoreInt32((*int32)(f), v)
+}
+
+func (f *openFlag) get() bool {
+	return atomic.LoadInt32((*int32)(f)) == 1
+}
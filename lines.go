@@ -0,0 +1,140 @@
+package display
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// LineWriter owns a single line of a display, so subsystems can each
+// update their line without coordinating. Get them with SplitLines.
+// A Write, Scroll or Flash replaces the running animation of the line.
+type LineWriter struct {
+	lcd  LCD
+	line Line
+	m    sync.Mutex
+	// stop of the running animation, nil if none
+	stop chan struct{}
+}
+
+// scrollGap separates the end of a scrolling text from its start.
+const scrollGap = "   "
+
+// SplitLines hands out a LineWriter for every line of lcd, two if it
+// doesn't keep track of its lines.
+func SplitLines(lcd LCD) []*LineWriter {
+	n := 2
+	if fb, ok := FramebufferOf(lcd); ok {
+		n = len(fb.Lines())
+	}
+	res := make([]*LineWriter, n)
+	for i := range res {
+		res[i] = &LineWriter{lcd: lcd, line: Line(i)}
+	}
+	return res
+}
+
+// Line written by w.
+func (w *LineWriter) Line() Line {
+	return w.line
+}
+
+// Write text on the line.
+func (w *LineWriter) Write(text string) error {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.stopLocked()
+	return w.lcd.Write(w.line, text)
+}
+
+// Scroll text through the line by a character every interval if it is
+// longer than the display, otherwise it is written as it is.
+func (w *LineWriter) Scroll(text string, interval time.Duration) error {
+	width := WidthOf(w.lcd)
+	if len(text) <= width {
+		return w.Write(text)
+	}
+	ring := text + scrollGap
+	pos := 0
+	return w.animate(interval, func() string {
+		txt := (ring + ring)[pos : pos+width]
+		pos = (pos + 1) % len(ring)
+		return txt
+	})
+}
+
+// Flash text by blanking the line every other interval.
+func (w *LineWriter) Flash(text string, interval time.Duration) error {
+	blank := strings.Repeat(" ", WidthOf(w.lcd))
+	on := false
+	return w.animate(interval, func() string {
+		on = !on
+		if on {
+			return text
+		}
+		return blank
+	})
+}
+
+// Stop the running animation, the line keeps its current text.
+func (w *LineWriter) Stop() {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.stopLocked()
+}
+
+// animate writes the first frame and the next ones every interval on
+// a goroutine, until it is replaced, the display is closed or a write
+// fails with ErrClosed.
+func (w *LineWriter) animate(interval time.Duration, frame func() string) error {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.stopLocked()
+	if err := w.lcd.Write(w.line, frame()); err != nil {
+		return err
+	}
+	var closed <-chan struct{}
+	for _, l := range chain(w.lcd) {
+		if n, ok := l.(closeNotifier); ok {
+			closed = n.closeNotify()
+			break
+		}
+	}
+	stop := make(chan struct{})
+	w.stop = stop
+	go func() {
+		c := ClockOf(w.lcd)
+		for {
+			select {
+			case <-c.After(interval):
+			case <-closed:
+				return
+			case <-stop:
+				return
+			}
+			w.m.Lock()
+			// replaced while waiting for the lock
+			if w.stop != stop {
+				w.m.Unlock()
+				return
+			}
+			err := w.lcd.Write(w.line, frame())
+			w.m.Unlock()
+			if err == ErrClosed {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// stopLocked must be called with the lock held.
+func (w *LineWriter) stopLocked() {
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
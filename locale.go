@@ -0,0 +1,128 @@
+package display
+
+import (
+	"fmt"
+	"sync"
+)
+
+type (
+	// Message identifies a built-in string shown on the display.
+	Message string
+	// Locale translates the messages, missing ones fall back to en.
+	// The panels show ASCII only, use transliterations like "ae" for
+	// umlauts unless the text is mapped to the character ROM.
+	Locale map[Message]string
+)
+
+// The built-in messages, some are format strings.
+const (
+	MsgYes            Message = "yes"
+	MsgNo             Message = "no"
+	MsgLocked         Message = "locked"
+	MsgPowerOff       Message = "power-off"
+	MsgPoweringOff    Message = "powering-off"
+	MsgPowerOffFailed Message = "power-off-failed"
+	// MsgPowerOffIn has the seconds left.
+	MsgPowerOffIn   Message = "power-off-in"
+	MsgShuttingDown Message = "shutting-down"
+	MsgDone         Message = "done"
+	MsgUnavailable  Message = "unavailable"
+	MsgAllHealthy   Message = "all-healthy"
+	MsgNTPSynced    Message = "ntp-synced"
+	MsgNTPNotSynced Message = "ntp-not-synced"
+	// MsgFailed has the name of what failed.
+	MsgFailed Message = "failed"
+)
+
+var (
+	localeM sync.RWMutex
+	locale  = "en"
+	locales = map[string]Locale{
+		"en": {
+			MsgYes:            "Yes",
+			MsgNo:             "No",
+			MsgLocked:         "Locked",
+			MsgPowerOff:       "Power off?",
+			MsgPoweringOff:    "Powering off...",
+			MsgPowerOffFailed: "Power off failed",
+			MsgPowerOffIn:     "Power off in %ds",
+			MsgShuttingDown:   "Shutting down...",
+			MsgDone:           "done",
+			MsgUnavailable:    "unavailable",
+			MsgAllHealthy:     "all healthy",
+			MsgNTPSynced:      "NTP synced",
+			MsgNTPNotSynced:   "NTP not synced",
+			MsgFailed:         "%s failed",
+		},
+		"de": {
+			MsgYes:            "Ja",
+			MsgNo:             "Nein",
+			MsgLocked:         "Gesperrt",
+			MsgPowerOff:       "Ausschalten?",
+			MsgPoweringOff:    "Schalte aus...",
+			MsgPowerOffFailed: "Fehler b. Aus",
+			MsgPowerOffIn:     "Aus in %ds",
+			MsgShuttingDown:   "Faehrt herunter",
+			MsgDone:           "fertig",
+			MsgUnavailable:    "nicht verfuegbar",
+			MsgAllHealthy:     "alle gesund",
+			MsgNTPSynced:      "NTP synchron",
+			MsgNTPNotSynced:   "NTP asynchron",
+			MsgFailed:         "%s Fehler",
+		},
+		"fr": {
+			MsgYes:            "Oui",
+			MsgNo:             "Non",
+			MsgLocked:         "Verrouille",
+			MsgPowerOff:       "Eteindre?",
+			MsgPoweringOff:    "Extinction...",
+			MsgPowerOffFailed: "Echec extinction",
+			MsgPowerOffIn:     "Arret dans %ds",
+			MsgShuttingDown:   "Arret en cours",
+			MsgDone:           "fait",
+			MsgUnavailable:    "indisponible",
+			MsgAllHealthy:     "tous sains",
+			MsgNTPSynced:      "NTP synchronise",
+			MsgNTPNotSynced:   "NTP non synchro",
+			MsgFailed:         "Echec %s",
+		},
+	}
+)
+
+// SetLocale switches the built-in strings to the locale name, like de.
+// It fails for unknown locales, add them with RegisterLocale.
+func SetLocale(name string) error {
+	localeM.Lock()
+	defer localeM.Unlock()
+
+	if _, ok := locales[name]; !ok {
+		return fmt.Errorf("unknown locale %q", name)
+	}
+	locale = name
+	return nil
+}
+
+// RegisterLocale adds or replaces the locale name, for example to ship
+// a Japanese panel with a katakana character mapping.
+func RegisterLocale(name string, l Locale) {
+	localeM.Lock()
+	defer localeM.Unlock()
+
+	locales[name] = l
+}
+
+// Tr returns the message in the current locale, formatted with args
+// if there are any.
+func Tr(id Message, args ...interface{}) string {
+	localeM.RLock()
+	s, ok := locales[locale][id]
+	if !ok {
+		s = locales["en"][id]
+	}
+	localeM.RUnlock()
+
+	if len(args) > 0 {
+		return fmt.Sprintf(s, args...)
+	}
+	return s
+}
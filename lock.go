@@ -0,0 +1,126 @@
+package display
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lock protects a front panel in shared spaces against curious fingers.
+// After IdleTimeout without button activity the buttons are locked and
+// the sequence has to be entered before events reach the listeners
+// again. While locked, a lock indicator is shown on the second line.
+// Lock wraps the display, use it instead of the display itself.
+type Lock struct {
+	LCD
+	seq   []Button
+	idle  time.Duration
+	clock Clock
+
+	m            sync.Mutex
+	locked       bool
+	pos          int
+	lastActivity time.Time
+	prev         []string
+}
+
+// NewLock locks the buttons of lcd after idle until seq is entered.
+// The sequence is matched on release, e.g. up, up, down, both.
+// The display starts locked.
+func NewLock(lcd LCD, idle time.Duration, seq ...Button) *Lock {
+	return &Lock{LCD: lcd, seq: seq, idle: idle, clock: ClockOf(lcd), locked: len(seq) > 0}
+}
+
+func (l *Lock) Unwrap() LCD {
+	return l.LCD
+}
+
+// Locked reports if the buttons are currently locked.
+func (l *Lock) Locked() bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.checkIdle(l.clock.Now())
+	return l.locked
+}
+
+// Lock the buttons immediately.
+func (l *Lock) Lock() {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.locked = len(l.seq) > 0
+	l.pos = 0
+}
+
+func (l *Lock) Listen(fn func(btn int, released bool) bool) {
+	l.ListenEvents(func(ev ButtonEvent) bool {
+		return fn(ev.Raw, ev.Released)
+	})
+}
+
+func (l *Lock) ListenEvents(fn func(ev ButtonEvent) bool) {
+	l.ListenEventsContext(context.Background(), fn)
+}
+
+func (l *Lock) ListenEventsContext(ctx context.Context, fn func(ev ButtonEvent) bool) {
+	ListenEventsContext(ctx, l.LCD, func(ev ButtonEvent) bool {
+		if l.pass(ev) {
+			return fn(ev)
+		}
+		return true
+	})
+}
+
+// pass reports if ev may reach the listeners and
+// matches it against the sequence while locked.
+func (l *Lock) pass(ev ButtonEvent) bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	at := ev.Time
+	if at.IsZero() {
+		at = l.clock.Now()
+	}
+	l.checkIdle(at)
+	l.lastActivity = at
+	if !l.locked {
+		return true
+	}
+	if !ev.Released {
+		return false
+	}
+	if ev.Button == l.seq[l.pos] {
+		l.pos++
+	} else if ev.Button == l.seq[0] {
+		l.pos = 1
+	} else {
+		l.pos = 0
+	}
+	if l.pos == len(l.seq) {
+		l.locked = false
+		l.pos = 0
+		if l.prev != nil {
+			_ = restore(l.LCD, l.prev)
+			l.prev = nil
+		}
+		return false
+	}
+	l.showIndicator()
+	return false
+}
+
+func (l *Lock) checkIdle(now time.Time) {
+	if !l.locked && l.idle > 0 && !l.lastActivity.IsZero() && now.Sub(l.lastActivity) > l.idle {
+		l.locked = len(l.seq) > 0
+		l.pos = 0
+	}
+}
+
+func (l *Lock) showIndicator() {
+	if l.prev == nil {
+		l.prev, _ = contentOf(l.LCD)
+	}
+	_ = l.LCD.Write(LineTwo, Tr(MsgLocked)+" "+strings.Repeat("*", l.pos))
+}
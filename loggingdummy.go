@@ -0,0 +1,206 @@
+package display
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// loggingDummy draws the display as ASCII art for development
+// without the hardware.
+type loggingDummy struct {
+	m         sync.Mutex
+	w         io.Writer
+	open      bool
+	fb        *Framebuffer
+	listeners *listeners
+	btnC      chan ButtonEvent
+	clock     Clock
+}
+
+// the raw ids of the logging dummy are the Button values
+var dummyButtons = map[int]Button{
+	int(ButtonUp):    ButtonUp,
+	int(ButtonDown):  ButtonDown,
+	int(ButtonBoth):  ButtonBoth,
+	int(ButtonBack):  ButtonBack,
+	int(ButtonEnter): ButtonEnter,
+}
+
+// NewLoggingDummy returns a display drawing every change to w as a 16x2
+// box. Simulate buttons with InjectButton, the raw ids are the Button
+// values like int(ButtonUp). WithClock is the only option it supports.
+func NewLoggingDummy(w io.Writer, opts ...Option) LCD {
+	return &loggingDummy{
+		w:         w,
+		open:      true,
+		fb:        NewFramebuffer(2),
+		listeners: newListeners(dummyButtons),
+		btnC:      make(chan ButtonEvent, DefaultQueueSize),
+		clock:     newOptions(opts).clock,
+	}
+}
+
+func (d *loggingDummy) Open() error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.open = true
+	return nil
+}
+
+func (d *loggingDummy) Write(line Line, text string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if !d.open {
+		return ErrClosed
+	}
+	if _, err := d.fb.Line(line); err != nil {
+		return err
+	}
+	d.fb.Set(line, prepareTxt(text))
+	d.draw()
+	return nil
+}
+
+func (d *loggingDummy) Enable(yes bool) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if !d.open {
+		return ErrClosed
+	}
+	d.fb.SetEnabled(yes)
+	d.draw()
+	return nil
+}
+
+// draw the box, the filled square of progress bars becomes #.
+func (d *loggingDummy) draw() {
+	c := d.fb.Content()
+	border := "+" + strings.Repeat("-", c16) + "+"
+	var b strings.Builder
+	b.WriteString(border)
+	if !c.Enabled {
+		b.WriteString(" off")
+	}
+	b.WriteString("\n")
+	for _, l := range c.Lines {
+		b.WriteString("|" + strings.Map(printable, prepareTxt(l)) + "|\n")
+	}
+	b.WriteString(border + "\n")
+	_, _ = io.WriteString(d.w, b.String())
+}
+
+func printable(r rune) rune {
+	switch {
+	case r == utf8.RuneError:
+		// the filled square isn't valid UTF-8
+		return '#'
+	case r < ' ' || r > '~':
+		return '?'
+	}
+	return r
+}
+
+func (d *loggingDummy) Listen(l func(btn int, released bool) bool) {
+	d.ListenEvents(func(ev ButtonEvent) bool {
+		return l(ev.Raw, ev.Released)
+	})
+}
+
+// Width of a line in characters.
+func (d *loggingDummy) Width() int {
+	return c16
+}
+
+func (d *loggingDummy) timeSource() Clock {
+	return d.clock
+}
+
+func (d *loggingDummy) buttonMap() map[int]Button {
+	return dummyButtons
+}
+
+// StopListening ends the pending Listen calls, the display stays open.
+func (d *loggingDummy) StopListening() {
+	d.listeners.stopAll()
+}
+
+func (d *loggingDummy) ListenEvents(l func(ev ButtonEvent) bool) {
+	d.ListenEventsContext(context.Background(), l)
+}
+
+func (d *loggingDummy) ListenEventsContext(ctx context.Context, l func(ev ButtonEvent) bool) {
+	d.listeners.listen(ctx, d.clock.Now(), d.isOpen, d.btnC, l)
+}
+
+func (d *loggingDummy) isOpen() bool {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.open
+}
+
+func (d *loggingDummy) InjectButton(btn int, released bool) error {
+	if !d.isOpen() {
+		return ErrClosed
+	}
+	ev := d.listeners.event(btn, released, d.clock.Now())
+	_, _ = fmt.Fprintf(d.w, "button %s released=%v\n", ev.Button, released)
+	DropOldest.sendEvent(d.btnC, ev)
+	return nil
+}
+
+// SetColor prints the color, so colored alerts can be developed
+// without the hardware.
+func (d *loggingDummy) SetColor(r, g, b uint8) error {
+	if !d.isOpen() {
+		return ErrClosed
+	}
+	_, _ = fmt.Fprintf(d.w, "color #%02x%02x%02x\n", r, g, b)
+	return nil
+}
+
+// Beep prints the pattern instead of beeping.
+func (d *loggingDummy) Beep(pattern BeepPattern) error {
+	if !d.isOpen() {
+		return ErrClosed
+	}
+	_, _ = fmt.Fprintf(d.w, "beep %v\n", []time.Duration(pattern))
+	return nil
+}
+
+func (d *loggingDummy) Framebuffer() *Framebuffer {
+	return d.fb
+}
+
+func (d *loggingDummy) GetLine(line Line) (string, error) {
+	return d.fb.Line(line)
+}
+
+func (d *loggingDummy) Snapshot() ([]string, error) {
+	return d.fb.Lines(), nil
+}
+
+func (d *loggingDummy) ObserveContent(fn func(c Content)) (cancel func()) {
+	return d.fb.Observe(fn)
+}
+
+func (d *loggingDummy) ObserveButtons(fn func(ev ButtonEvent)) (cancel func()) {
+	return d.listeners.observe(fn)
+}
+
+func (d *loggingDummy) Close() error {
+	d.m.Lock()
+	d.open = false
+	d.m.Unlock()
+
+	d.listeners.wakeAll()
+	return nil
+}
@@ -0,0 +1,10 @@
+module github.com/artvel/display/menu
+
+go 1.16
+
+replace github.com/artvel/display => ../
+
+require (
+	github.com/artvel/display v0.0.0-00010101000000-000000000000
+	gopkg.in/yaml.v3 v3.0.1
+)
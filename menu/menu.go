@@ -0,0 +1,237 @@
+/*
+Package menu runs a menu tree on a display. The tree is usually
+defined in YAML, so product specific menus stay out of the code:
+
+	label: Settings
+	items:
+	  - label: Network
+	    items:
+	      - label: Show IP
+	        action: show-ip
+	  - label: Restart SMB
+	    action: exec:systemctl restart smbd
+	    confirm: true
+
+Actions are resolved against the Handlers of the Menu. Actions
+starting with exec: run the command instead and show the first line
+of its output.
+
+It is a module of its own to keep the YAML parser out of the
+dependencies of the display package.
+*/
+package menu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/artvel/display"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+type (
+	// Item of the tree, either a submenu with Items or an Action.
+	Item struct {
+		Label  string `yaml:"label"`
+		Action string `yaml:"action"`
+		// Confirm asks before the action runs.
+		Confirm bool    `yaml:"confirm"`
+		Items   []*Item `yaml:"items"`
+	}
+	// Handler of an action, the menu is shown again once it returned.
+	// The display belongs to the handler until then.
+	Handler func(ctx context.Context, lcd display.LCD) error
+	// Menu shows Root on a display.
+	Menu struct {
+		Root     *Item
+		Handlers map[string]Handler
+		// Keymap defaults to the DefaultKeymap of the display.
+		Keymap display.Keymap
+	}
+)
+
+// execPrefix marks actions running a command
+const execPrefix = "exec:"
+
+// ResultDuration the result of an action is shown.
+var ResultDuration = 2 * time.Second
+
+// Load the tree from a YAML file.
+func Load(path string) (*Item, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse the tree from YAML.
+func Parse(b []byte) (*Item, error) {
+	var root Item
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// Validate the tree, every action needs a handler and every item
+// either an action or items.
+func (m *Menu) Validate() error {
+	if m.Root == nil {
+		return errors.New("menu: no root")
+	}
+	return m.validate(m.Root, m.Root.Label)
+}
+
+func (m *Menu) validate(it *Item, path string) error {
+	switch {
+	case it.Label == "":
+		return fmt.Errorf("menu: %s: item without label", path)
+	case it.Action != "" && len(it.Items) > 0:
+		return fmt.Errorf("menu: %s: both action and items", path)
+	case it.Action != "":
+		if _, ok := m.handler(it.Action); !ok {
+			return fmt.Errorf("menu: %s: unknown action %q", path, it.Action)
+		}
+	case it != m.Root && len(it.Items) == 0:
+		return fmt.Errorf("menu: %s: neither action nor items", path)
+	}
+	for _, child := range it.Items {
+		if err := m.validate(child, path+"/"+child.Label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Menu) handler(action string) (Handler, bool) {
+	if strings.HasPrefix(action, execPrefix) {
+		args := strings.Fields(strings.TrimPrefix(action, execPrefix))
+		if len(args) == 0 {
+			return nil, false
+		}
+		return execHandler(args), true
+	}
+	h, ok := m.Handlers[action]
+	return h, ok
+}
+
+// Run the menu on lcd until back is pressed on the root or ctx is done.
+// The previous content is restored afterwards.
+func (m *Menu) Run(ctx context.Context, lcd display.LCD) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if prev, err := display.SaveState(lcd); err == nil {
+		defer func() {
+			_ = display.RestoreState(lcd, prev)
+		}()
+	}
+	type level struct {
+		item *Item
+		pos  int
+	}
+	stack := []level{{item: m.Root}}
+	render := func() error {
+		cur := stack[len(stack)-1]
+		if err := lcd.Write(display.LineOne, cur.item.Label); err != nil {
+			return err
+		}
+		if len(cur.item.Items) == 0 {
+			return lcd.Write(display.LineTwo, "")
+		}
+		child := cur.item.Items[cur.pos]
+		marker := ""
+		if len(child.Items) > 0 {
+			marker = ">"
+		}
+		return lcd.Write(display.LineTwo, display.CurrentTheme().Cursor+" "+child.Label+marker)
+	}
+	if err := render(); err != nil {
+		return err
+	}
+	var err error
+	display.ListenKeysContext(ctx, lcd, m.Keymap, func(key display.Key, ev display.ButtonEvent) bool {
+		cur := &stack[len(stack)-1]
+		n := len(cur.item.Items)
+		switch key {
+		case display.KeyUp:
+			if n > 0 {
+				cur.pos = (cur.pos + n - 1) % n
+			}
+		case display.KeyDown:
+			if n > 0 {
+				cur.pos = (cur.pos + 1) % n
+			}
+		case display.KeyBack:
+			if len(stack) == 1 {
+				return false
+			}
+			stack = stack[:len(stack)-1]
+		case display.KeySelect:
+			if n == 0 {
+				break
+			}
+			child := cur.item.Items[cur.pos]
+			if len(child.Items) > 0 {
+				stack = append(stack, level{item: child})
+				break
+			}
+			m.run(ctx, lcd, child)
+		default:
+			return true
+		}
+		err = render()
+		return err == nil && ctx.Err() == nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+// run the action of it and show errors.
+func (m *Menu) run(ctx context.Context, lcd display.LCD, it *Item) {
+	if it.Confirm {
+		yes, err := display.ConfirmWith(lcd, it.Label+"?", display.ConfirmOptions{Keymap: m.Keymap})
+		if err != nil || !yes {
+			return
+		}
+	}
+	h, _ := m.handler(it.Action)
+	if err := h(ctx, lcd); err != nil {
+		show(ctx, lcd, display.Tr(display.MsgFailed, it.Label), err.Error())
+	}
+}
+
+// execHandler runs the command and shows the first line of its output.
+func execHandler(args []string) Handler {
+	return func(ctx context.Context, lcd display.LCD) error {
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+		if err != nil {
+			return err
+		}
+		result := strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0]
+		if result == "" {
+			result = display.Tr(display.MsgDone)
+		}
+		show(ctx, lcd, args[0], result)
+		return nil
+	}
+}
+
+// show a result for ResultDuration.
+func show(ctx context.Context, lcd display.LCD, title, msg string) {
+	_ = lcd.Write(display.LineOne, title)
+	_ = lcd.Write(display.LineTwo, msg)
+	t := time.NewTimer(ResultDuration)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,174 @@
+package display
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+type (
+	// Middleware decorates a display, for example to log its writes.
+	// Combine them with Use.
+	Middleware func(LCD) LCD
+
+	// decorator passes everything to the wrapped display,
+	// the middlewares embed it and override what they need.
+	decorator struct {
+		LCD
+	}
+
+	logging struct {
+		decorator
+		l *log.Logger
+	}
+	metrics struct {
+		decorator
+		observe func(op string, took time.Duration, err error)
+		clock   Clock
+	}
+	throttle struct {
+		decorator
+		interval time.Duration
+		clock    Clock
+		m        sync.Mutex
+		last     time.Time
+	}
+	readOnly struct {
+		decorator
+	}
+)
+
+// ErrReadOnly is returned by displays decorated with ReadOnly.
+var ErrReadOnly = errors.New("display is read-only")
+
+// Use decorates lcd with mws, the first one is the outermost.
+func Use(lcd LCD, mws ...Middleware) LCD {
+	for i := len(mws) - 1; i >= 0; i-- {
+		lcd = mws[i](lcd)
+	}
+	return lcd
+}
+
+func (d decorator) Unwrap() LCD {
+	return d.LCD
+}
+
+func (d decorator) ListenEvents(l func(ev ButtonEvent) bool) {
+	ListenEvents(d.LCD, l)
+}
+
+func (d decorator) ListenEventsContext(ctx context.Context, l func(ev ButtonEvent) bool) {
+	ListenEventsContext(ctx, d.LCD, l)
+}
+
+// Logging logs all writes and errors to l, the standard logger if nil.
+func Logging(l *log.Logger) Middleware {
+	if l == nil {
+		l = log.Default()
+	}
+	return func(lcd LCD) LCD {
+		return &logging{decorator: decorator{lcd}, l: l}
+	}
+}
+
+func (d *logging) Write(line Line, text string) error {
+	err := d.LCD.Write(line, text)
+	if err != nil {
+		d.l.Printf("display: write %d %q: %v", line, text, err)
+	} else {
+		d.l.Printf("display: write %d %q", line, text)
+	}
+	return err
+}
+
+func (d *logging) Enable(yes bool) error {
+	err := d.LCD.Enable(yes)
+	if err != nil {
+		d.l.Printf("display: enable %v: %v", yes, err)
+	} else {
+		d.l.Printf("display: enable %v", yes)
+	}
+	return err
+}
+
+func (d *logging) Open() error {
+	err := d.LCD.Open()
+	if err != nil {
+		d.l.Printf("display: open: %v", err)
+	}
+	return err
+}
+
+// Metrics calls observe after every operation with its name, open,
+// write, enable or close, the time it took and the error. Use it to
+// feed the metrics system of the application.
+func Metrics(observe func(op string, took time.Duration, err error)) Middleware {
+	return func(lcd LCD) LCD {
+		return &metrics{decorator: decorator{lcd}, observe: observe, clock: ClockOf(lcd)}
+	}
+}
+
+func (d *metrics) measure(op string, fn func() error) error {
+	start := d.clock.Now()
+	err := fn()
+	d.observe(op, d.clock.Now().Sub(start), err)
+	return err
+}
+
+func (d *metrics) Open() error {
+	return d.measure("open", d.LCD.Open)
+}
+
+func (d *metrics) Write(line Line, text string) error {
+	return d.measure("write", func() error { return d.LCD.Write(line, text) })
+}
+
+func (d *metrics) Enable(yes bool) error {
+	return d.measure("enable", func() error { return d.LCD.Enable(yes) })
+}
+
+func (d *metrics) Close() error {
+	return d.measure("close", d.LCD.Close)
+}
+
+// Throttle delays writes so at most one happens per interval.
+func Throttle(interval time.Duration) Middleware {
+	return func(lcd LCD) LCD {
+		return &throttle{decorator: decorator{lcd}, interval: interval, clock: ClockOf(lcd)}
+	}
+}
+
+func (d *throttle) Write(line Line, text string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if wait := d.interval - d.clock.Now().Sub(d.last); wait > 0 {
+		d.clock.Sleep(wait)
+	}
+	d.last = d.clock.Now()
+	return d.LCD.Write(line, text)
+}
+
+// Retries tries writes and Enable calls up to attempts times,
+// see Retry for the details.
+func Retries(attempts int) Middleware {
+	return func(lcd LCD) LCD {
+		return Retry(lcd, RetryPolicy{Attempts: attempts})
+	}
+}
+
+// ReadOnly rejects writes and Enable calls with ErrReadOnly, for
+// example during maintenance. Buttons still work.
+func ReadOnly(lcd LCD) LCD {
+	return &readOnly{decorator{lcd}}
+}
+
+func (d *readOnly) Write(line Line, text string) error {
+	return ErrReadOnly
+}
+
+func (d *readOnly) Enable(yes bool) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,84 @@
+package display
+
+import "sync"
+
+type (
+	// offlineQueue keeps the writes issued while a display is
+	// disconnected and replays them once it is open again.
+	// Only the latest write per line is kept.
+	offlineQueue struct {
+		m      sync.Mutex
+		depth  int
+		writes []offlineWrite
+		enable *bool
+	}
+	offlineWrite struct {
+		line Line
+		txt  string
+	}
+)
+
+// WithOfflineBuffer keeps up to depth writes while the display is
+// disconnected instead of returning ErrClosed and replays the final
+// state once it is opened again. The latest write per line wins,
+// if there are more lines than depth the oldest is dropped.
+func WithOfflineBuffer(depth int) Option {
+	return func(o *options) {
+		o.offlineDepth = depth
+	}
+}
+
+func newOfflineQueue(depth int) *offlineQueue {
+	return &offlineQueue{depth: depth}
+}
+
+// write queues txt for line and reports false if buffering is disabled.
+func (q *offlineQueue) write(line Line, txt string) bool {
+	if q.depth <= 0 {
+		return false
+	}
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	for i, w := range q.writes {
+		if w.line == line {
+			q.writes = append(q.writes[:i], q.writes[i+1:]...)
+			break
+		}
+	}
+	q.writes = append(q.writes, offlineWrite{line: line, txt: txt})
+	if len(q.writes) > q.depth {
+		q.writes = q.writes[len(q.writes)-q.depth:]
+	}
+	return true
+}
+
+// setEnabled queues an Enable call and reports false if buffering is disabled.
+func (q *offlineQueue) setEnabled(yes bool) bool {
+	if q.depth <= 0 {
+		return false
+	}
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	q.enable = &yes
+	return true
+}
+
+// replay the queued state on lcd. Writes failing are queued again.
+func (q *offlineQueue) replay(lcd LCD) error {
+	q.m.Lock()
+	writes, enable := q.writes, q.enable
+	q.writes, q.enable = nil, nil
+	q.m.Unlock()
+
+	for _, w := range writes {
+		if err := lcd.Write(w.line, w.txt); err != nil {
+			return err
+		}
+	}
+	if enable != nil {
+		return lcd.Enable(*enable)
+	}
+	return nil
+}
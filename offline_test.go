@@ -0,0 +1,43 @@
+package display
+
+import (
+	"github.com/artvel/display/emulator"
+	"io"
+	"testing"
+)
+
+func TestOfflineBufferReplaysOnOpen(t *testing.T) {
+	var emus []*emulator.Asustor
+	connect := func() (io.ReadWriteCloser, error) {
+		emu, con := emulator.NewAsustor()
+		emus = append(emus, emu)
+		return con, nil
+	}
+	c := newFakeClock()
+	lcd, err := newAsustor("", connect, newOptions([]Option{WithClock(c), WithOfflineBuffer(2)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lcd.Close()
+
+	start := c.Now()
+	if err = lcd.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, txt := range []string{"old", "first", "second"} {
+		if err = lcd.Write(Line(len(txt)%2), txt); err != nil {
+			t.Fatalf("write while closed: %v", err)
+		}
+	}
+	if err = lcd.Open(); err != nil {
+		t.Fatal(err)
+	}
+	lines := emus[len(emus)-1].Lines()
+	if lines[0] != "second" || lines[1] != "first" {
+		t.Errorf("replayed %q", lines)
+	}
+	// the pacer slept on the fake clock only
+	if c.Now().Sub(start) < DefaultDelayBetweenWrites {
+		t.Errorf("writes weren't paced")
+	}
+}
@@ -0,0 +1,169 @@
+package display
+
+import (
+	"github.com/artvel/display/qnapproto"
+	"time"
+)
+
+type (
+	// Option configures a display implementation on construction.
+	Option func(o *options)
+
+	// options collects the settings of all implementations.
+	// Zero values mean "use the default of the implementation".
+	options struct {
+		writeDelay     time.Duration
+		serialOpener   SerialOpener
+		clock          Clock
+		offlineDepth   int
+		queueSize      int
+		dropPolicy     DropPolicy
+		disabledPolicy DisabledPolicy
+		tracer         Tracer
+		noDummy        bool
+		writeTimeout   time.Duration
+		closeTimeout   time.Duration
+		stateHook      func(from, to ConnState)
+		tty            string
+		baudRate       uint
+		dataBits       uint
+		stopBits       uint
+		parity         *Parity
+		flowControl    *bool
+		rs485          *RS485
+		passive        time.Duration
+		noPortScan     bool
+		qnapHandshakes []qnapproto.Handshake
+		retries        int
+		width          int
+		lines          int
+		readTimeout    time.Duration
+		noAck          bool
+		anyReady       bool
+		writeHook      func(line Line, text string)
+		buttonLog      *ButtonLog
+		autoRepeat     bool
+		repeatDelay    time.Duration
+		repeatInterval time.Duration
+	}
+)
+
+const (
+	// DefaultDelayBetweenWrites the ASUSTOR firmware needs
+	// to process a frame before the next one can be sent.
+	DefaultDelayBetweenWrites = 10 * time.Millisecond
+	// DefaultQnapDelayBetweenWrites is much higher as the QNAP
+	// panel is connected with 1200 baud only.
+	DefaultQnapDelayBetweenWrites = 135 * time.Millisecond
+	// DefaultWriteTimeout is plenty for a frame even at 1200 baud.
+	DefaultWriteTimeout = 2 * time.Second
+	// DefaultCloseTimeout Close waits for the background goroutines.
+	DefaultCloseTimeout = time.Second
+	// DefaultAsustorReadTimeout the ASUSTOR driver waits for the
+	// acknowledgement of a frame. The firmware replies within a few
+	// milliseconds, a frame without one is sent again. Once a panel
+	// acknowledged late the driver waits that much longer.
+	DefaultAsustorReadTimeout = 40 * time.Millisecond
+	// DefaultQnapReadTimeout the QNAP driver waits for the reply to
+	// its handshake. At 1200 baud the frames alone take about 35ms
+	// each way and the firmware answers only after it finished
+	// drawing, without a reply the display isn't working.
+	DefaultQnapReadTimeout = 300 * time.Millisecond
+)
+
+// WithWriteDelay overrides the pause between two writes.
+// Some firmware revisions need more time than the defaults,
+// others are fine with less.
+func WithWriteDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.writeDelay = d
+	}
+}
+
+// WithWriteTimeout limits how long sending a frame may block, for
+// example on a wedged serial port. Once it passes the operation fails
+// with ErrWriteTimeout, the connection is closed and the error is
+// reported on Errors. Open reconnects, combine it with
+// WithOfflineBuffer to keep the writes until then.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.writeTimeout = d
+	}
+}
+
+// WithCloseTimeout limits how long Close waits for the listeners and
+// the reader to stop.
+func WithCloseTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.closeTimeout = d
+	}
+}
+
+// WithReadTimeout overrides how long the drivers wait for a reply of
+// the display, DefaultAsustorReadTimeout and DefaultQnapReadTimeout by
+// default. Slow USB serial adapters may need more.
+func WithReadTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.readTimeout = d
+	}
+}
+
+// WithAck sets whether the ASUSTOR driver waits for the acknowledgement
+// of a frame, it overrides the NoAck quirk of a known model.
+func WithAck(yes bool) Option {
+	return func(o *options) {
+		o.noAck = !yes
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{clock: realClock{}}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// durationOr returns d or the fallback if d is not set.
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return fallback
+}
+
+func intOr(v, fallback int) int {
+	if v > 0 {
+		return v
+	}
+	return fallback
+}
+
+func stringOr(s, fallback string) string {
+	if s != "" {
+		return s
+	}
+	return fallback
+}
+
+// WithQnapHandshake limits the QNAP driver to the given handshakes
+// instead of trying all of qnapproto.Handshakes, for a model with a
+// known or a custom exchange. The AnyReady quirk doesn't add to them.
+func WithQnapHandshake(h ...qnapproto.Handshake) Option {
+	return func(o *options) {
+		o.qnapHandshakes = h
+	}
+}
+
+// OnWrite calls fn after every write the display confirmed with the
+// text as shown, cut and padded, for example to mirror or log the
+// content without wrapping the display. Buffered writes of an offline
+// display are reported once they are replayed. fn is called
+// synchronously by the driver and must not call the display.
+func OnWrite(fn func(line Line, text string)) Option {
+	return func(o *options) {
+		o.writeHook = fn
+	}
+}
@@ -0,0 +1,26 @@
+package display
+
+import "time"
+
+// pacer keeps track of the delay the display
+// needs between two writes to process them.
+type pacer struct {
+	clock Clock
+	delay time.Duration
+	last  time.Time
+}
+
+// wait blocks until the previous write was processed
+// and marks the beginning of the next one.
+func (p *pacer) wait() {
+	p.settle()
+	p.last = p.clock.Now()
+}
+
+// settle blocks until the previous write was processed.
+func (p *pacer) settle() {
+	timeDiff := p.last.Add(p.delay).Sub(p.clock.Now())
+	if timeDiff > 0 {
+		p.clock.Sleep(timeDiff)
+	}
+}
@@ -0,0 +1,27 @@
+package display
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPacerWaitsForTheDelay(t *testing.T) {
+	c := newFakeClock()
+	p := pacer{clock: c, delay: 50 * time.Millisecond}
+	start := c.Now()
+	p.wait()
+	if got := c.Now().Sub(start); got != 0 {
+		t.Fatalf("first write waited %v", got)
+	}
+	c.Advance(20 * time.Millisecond)
+	p.wait()
+	if got := c.Now().Sub(start); got != 50*time.Millisecond {
+		t.Errorf("second write started after %v, want 50ms", got)
+	}
+	c.Advance(time.Second)
+	before := c.Now()
+	p.wait()
+	if got := c.Now().Sub(before); got != 0 {
+		t.Errorf("write after a pause waited %v", got)
+	}
+}
@@ -0,0 +1,359 @@
+/*
+Package pages rotates status pages on a display and shows toasts,
+like alerts, on top of them. It is the heart of the displayd daemon
+but can be used by any application owning a panel.
+*/
+package pages
+
+import (
+	"context"
+	"github.com/artvel/display"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// Board shows its pages one after another and preempts them
+	// with the toasts of the highest priority.
+	Board struct {
+		lcd      display.LCD
+		interval time.Duration
+		clock    display.Clock
+
+		m      sync.Mutex
+		pages  []*page
+		toasts []*toast
+		tick   int
+		blank  bool
+		paused int
+		shown  []string
+		// idle is played without pages and toasts
+		idle       *Animation
+		frame      int
+		quietUntil time.Time
+
+		changed chan struct{}
+	}
+
+	// Toast is shown on top of the pages. If there are several,
+	// the ones with the highest priority rotate and the others wait.
+	Toast struct {
+		// ID replaces a toast with the same ID and is used to
+		// dismiss it. Optional.
+		ID    string
+		Lines []string
+		// Priority of the toast, see PriorityNormal and friends.
+		Priority Priority
+		// Duration the toast is kept, zero keeps it until dismissed.
+		Duration time.Duration
+		// Flash the toast to draw attention.
+		Flash bool
+	}
+
+	// Priority of a toast, higher wins.
+	Priority int
+
+	// Option configures NewBoard.
+	Option func(*Board)
+
+	page struct {
+		name  string
+		lines []string
+	}
+	toast struct {
+		Toast
+		expires time.Time
+		seq     int
+	}
+	// ticker on a display.Clock, the time package has none.
+	ticker struct {
+		C     chan time.Time
+		clock display.Clock
+		d     time.Duration
+		m     sync.Mutex
+		timer display.Timer
+		done  bool
+	}
+)
+
+const (
+	PriorityNormal   Priority = 0
+	PriorityHigh     Priority = 10
+	PriorityCritical Priority = 20
+
+	// DefaultInterval between two pages.
+	DefaultInterval = 5 * time.Second
+	// flashInterval toggles flashing toasts
+	flashInterval = 500 * time.Millisecond
+)
+
+// NewBoard for lcd showing each page for interval,
+// zero means DefaultInterval.
+func NewBoard(lcd display.LCD, interval time.Duration, opts ...Option) *Board {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	b := &Board{lcd: lcd, interval: interval, clock: display.ClockOf(lcd), changed: make(chan struct{}, 1)}
+	for _, o := range opts {
+		o(b)
+	}
+	return b
+}
+
+// WithClock replaces the clock of the display for the rotation,
+// the toasts and the polls of a Scheduler.
+func WithClock(c display.Clock) Option {
+	return func(b *Board) {
+		if c != nil {
+			b.clock = c
+		}
+	}
+}
+
+// Set the lines of the page name, it is added to the rotation
+// if it doesn't exist yet.
+func (b *Board) Set(name string, lines ...string) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	defer b.notify()
+	b.quiet()
+	for _, p := range b.pages {
+		if p.name == name {
+			p.lines = lines
+			return
+		}
+	}
+	b.pages = append(b.pages, &page{name: name, lines: lines})
+}
+
+// Remove the page name from the rotation.
+func (b *Board) Remove(name string) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	for i, p := range b.pages {
+		if p.name == name {
+			b.pages = append(b.pages[:i], b.pages[i+1:]...)
+			b.notify()
+			return
+		}
+	}
+}
+
+// Show a toast right away if there is none with a higher priority.
+func (b *Board) Show(t Toast) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	defer b.notify()
+	b.quiet()
+	nt := &toast{Toast: t}
+	if t.Duration > 0 {
+		nt.expires = b.clock.Now().Add(t.Duration)
+	}
+	if t.ID != "" {
+		for i, old := range b.toasts {
+			if old.ID == t.ID {
+				nt.seq = old.seq
+				b.toasts[i] = nt
+				return
+			}
+		}
+	}
+	nt.seq = len(b.toasts)
+	if n := len(b.toasts); n > 0 {
+		nt.seq = b.toasts[n-1].seq + 1
+	}
+	b.toasts = append(b.toasts, nt)
+}
+
+// Dismiss the toast with id.
+func (b *Board) Dismiss(id string) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	for i, t := range b.toasts {
+		if t.ID == id {
+			b.toasts = append(b.toasts[:i], b.toasts[i+1:]...)
+			b.notify()
+			return
+		}
+	}
+}
+
+// Pause drawing until resume is called, for example while a dialog
+// owns the display. Everything is redrawn on resume.
+func (b *Board) Pause() (resume func()) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.paused++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.m.Lock()
+			defer b.m.Unlock()
+
+			b.paused--
+			b.shown = nil
+			b.notify()
+		})
+	}
+}
+
+// Run the rotation until ctx is done.
+func (b *Board) Run(ctx context.Context) error {
+	rotate := newTicker(b.clock, b.interval)
+	defer rotate.Stop()
+	flash := newTicker(b.clock, flashInterval)
+	defer flash.Stop()
+	if cancel, ok := display.ObserveButtons(b.lcd, b.pressed); ok {
+		defer cancel()
+	}
+	var (
+		frames     *ticker
+		frameC     <-chan time.Time
+		frameEvery time.Duration
+	)
+	defer func() {
+		if frames != nil {
+			frames.Stop()
+		}
+	}()
+	b.draw()
+	for {
+		if d := b.idleInterval(); d != frameEvery {
+			if frames != nil {
+				frames.Stop()
+				frames, frameC = nil, nil
+			}
+			if d > 0 {
+				frames = newTicker(b.clock, d)
+				frameC = frames.C
+			}
+			frameEvery = d
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-frameC:
+			b.m.Lock()
+			b.frame++
+			b.m.Unlock()
+		case <-rotate.C:
+			b.m.Lock()
+			b.tick++
+			b.m.Unlock()
+		case <-flash.C:
+			b.m.Lock()
+			b.blank = !b.blank
+			b.m.Unlock()
+		case <-b.changed:
+		}
+		b.draw()
+	}
+}
+
+func (b *Board) notify() {
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+// draw the current page or toast, only changed lines are written.
+func (b *Board) draw() {
+	b.m.Lock()
+	if b.paused > 0 {
+		b.m.Unlock()
+		return
+	}
+	lines := b.current(b.clock.Now())
+	shown := b.shown
+	b.m.Unlock()
+
+	next := make([]string, display.LineCountOf(b.lcd))
+	copy(next, lines)
+	for i, txt := range next {
+		if i < len(shown) && shown[i] == txt {
+			continue
+		}
+		if err := b.lcd.Write(display.Line(i), txt); err != nil {
+			log.Println("pages:", err)
+			// write everything again on the next round
+			next = nil
+			break
+		}
+	}
+	b.m.Lock()
+	b.shown = next
+	b.m.Unlock()
+}
+
+// current lines to show, expired toasts are removed.
+func (b *Board) current(now time.Time) []string {
+	active := b.toasts[:0]
+	for _, t := range b.toasts {
+		if t.expires.IsZero() || now.Before(t.expires) {
+			active = append(active, t)
+		}
+	}
+	b.toasts = active
+	if len(active) > 0 {
+		top := make([]*toast, 0, len(active))
+		for _, t := range active {
+			if len(top) > 0 && t.Priority < top[0].Priority {
+				continue
+			}
+			if len(top) > 0 && t.Priority > top[0].Priority {
+				top = top[:0]
+			}
+			top = append(top, t)
+		}
+		sort.Slice(top, func(i, j int) bool { return top[i].seq < top[j].seq })
+		t := top[b.tick%len(top)]
+		if t.Flash && b.blank {
+			return nil
+		}
+		return t.Lines
+	}
+	if len(b.pages) == 0 {
+		return b.idleFrame(now)
+	}
+	return b.pages[b.tick%len(b.pages)].lines
+}
+
+func newTicker(c display.Clock, d time.Duration) *ticker {
+	t := &ticker{C: make(chan time.Time, 1), clock: c, d: d}
+	t.schedule()
+	return t
+}
+
+func (t *ticker) schedule() {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if t.done {
+		return
+	}
+	t.timer = t.clock.AfterFunc(t.d, func() {
+		// drop ticks like time.Ticker does for slow receivers
+		select {
+		case t.C <- t.clock.Now():
+		default:
+		}
+		t.schedule()
+	})
+}
+
+// Stop the ticker, no more ticks are sent.
+func (t *ticker) Stop() {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	t.done = true
+	t.timer.Stop()
+}
@@ -0,0 +1,122 @@
+package pages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/artvel/display"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type (
+	// Docker shows the running and total containers of the Docker
+	// engine and raises a toast for every unhealthy container.
+	Docker struct {
+		// Socket of the engine, defaults to /var/run/docker.sock.
+		Socket string
+		// PollInterval defaults to DefaultPollInterval.
+		PollInterval time.Duration
+
+		client *http.Client
+		alerts []Toast
+	}
+
+	// container as listed by the engine API
+	container struct {
+		Names  []string `json:"Names"`
+		State  string   `json:"State"`
+		Status string   `json:"Status"`
+	}
+)
+
+func (d *Docker) Name() string {
+	return "Docker"
+}
+
+func (d *Docker) Interval() time.Duration {
+	return d.PollInterval
+}
+
+// Poll the containers and report the running and unhealthy ones.
+func (d *Docker) Poll(ctx context.Context) ([]string, error) {
+	if d.client == nil {
+		socket := d.Socket
+		if socket == "" {
+			socket = "/var/run/docker.sock"
+		}
+		d.client = &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		}
+	}
+	list, err := containers(ctx, d.client)
+	if err != nil {
+		return nil, err
+	}
+	running := 0
+	var unhealthy []string
+	d.alerts = nil
+	for _, c := range list {
+		if c.State == "running" {
+			running++
+		}
+		if c.unhealthy() {
+			unhealthy = append(unhealthy, c.name())
+			d.alerts = append(d.alerts, Toast{
+				ID:       "docker/" + c.name(),
+				Lines:    []string{"!unhealthy", c.name()},
+				Priority: PriorityHigh,
+			})
+		}
+	}
+	second := display.Tr(display.MsgAllHealthy)
+	if len(unhealthy) > 0 {
+		second = fmt.Sprintf("%d sick %s", len(unhealthy), strings.Join(unhealthy, ","))
+	}
+	return []string{fmt.Sprintf("Docker %d/%d up", running, len(list)), second}, nil
+}
+
+// Alerts of the unhealthy containers.
+func (d *Docker) Alerts() []Toast {
+	return d.alerts
+}
+
+// containers lists all containers, stopped ones included.
+func containers(ctx context.Context, client *http.Client) ([]container, error) {
+	// the host is ignored by the unix dialer
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker/containers/json?all=1", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("docker: %s", resp.Status)
+	}
+	var list []container
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	return list, err
+}
+
+func (c container) name() string {
+	if len(c.Names) == 0 {
+		return "?"
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// unhealthy according to the status, like Up 2 hours (unhealthy).
+func (c container) unhealthy() bool {
+	return strings.Contains(c.Status, "(unhealthy)")
+}
@@ -0,0 +1,121 @@
+package pages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/artvel/display"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// HTTP polls a JSON endpoint and renders every line with a
+// text/template of the decoded response, so the panel can show any
+// local web service without writing Go, like a download queue:
+//
+//	&pages.HTTP{
+//		URL:   "http://localhost:9091/api/stats",
+//		Lines: []string{"Downloads", "{{.active}} of {{.total}}", "{{progress .percent}}"},
+//	}
+//
+// Missing fields fail the poll. Besides the builtins of text/template
+// there is progress, rendering a number from 0 to 100 as bar.
+type HTTP struct {
+	URL string
+	// Title is the name of the source, defaults to the host of the URL.
+	Title string
+	// Lines are the templates of the lines.
+	Lines []string
+	// Header is sent along, for example an API token.
+	Header http.Header
+	// PollInterval defaults to DefaultPollInterval.
+	PollInterval time.Duration
+	// Client defaults to one with a 5 seconds timeout.
+	Client *http.Client
+
+	tmpls []*template.Template
+}
+
+var httpFuncs = template.FuncMap{
+	"progress": func(v interface{}) (string, error) {
+		switch n := v.(type) {
+		case float64:
+			return display.Progress(int(n)), nil
+		case int:
+			return display.Progress(n), nil
+		}
+		return "", fmt.Errorf("progress: want a number, got %T", v)
+	},
+}
+
+func (h *HTTP) Name() string {
+	if h.Title != "" {
+		return h.Title
+	}
+	if u, err := url.Parse(h.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "HTTP"
+}
+
+func (h *HTTP) Interval() time.Duration {
+	return h.PollInterval
+}
+
+// Parse the templates, Poll does it on the first call otherwise.
+func (h *HTTP) Parse() error {
+	if h.tmpls != nil {
+		return nil
+	}
+	tmpls := make([]*template.Template, len(h.Lines))
+	for i, l := range h.Lines {
+		t, err := template.New(fmt.Sprint(i)).Funcs(httpFuncs).Option("missingkey=error").Parse(l)
+		if err != nil {
+			return err
+		}
+		tmpls[i] = t
+	}
+	h.tmpls = tmpls
+	return nil
+}
+
+func (h *HTTP) Poll(ctx context.Context) ([]string, error) {
+	if err := h.Parse(); err != nil {
+		return nil, err
+	}
+	if h.Client == nil {
+		h.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range h.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", h.URL, resp.Status)
+	}
+	var data interface{}
+	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	lines := make([]string, len(h.tmpls))
+	for i, t := range h.tmpls {
+		var b strings.Builder
+		if err = t.Execute(&b, data); err != nil {
+			return nil, err
+		}
+		lines[i] = b.String()
+	}
+	return lines, nil
+}
@@ -0,0 +1,198 @@
+package pages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// HWMon shows the temperatures and fan speeds of the hwmon drivers,
+	// the same lm-sensors reads. Sensors past their limit raise a
+	// flashing toast.
+	HWMon struct {
+		// Root defaults to /sys/class/hwmon.
+		Root string
+		// Sensors to show, all temperatures and fans if empty.
+		Sensors []Sensor
+		// PollInterval defaults to DefaultPollInterval.
+		PollInterval time.Duration
+
+		alerts []Toast
+	}
+
+	// Sensor selects a hwmon input.
+	Sensor struct {
+		// ID is the chip name and the input, like coretemp/temp1
+		// or nct6775/fan2.
+		ID string
+		// Label shown, defaults to the label of the driver or the ID.
+		Label string
+		// Limit raises an alert if a temperature in °C exceeds it or
+		// a fan in RPM falls below it. Zero disables the alert.
+		Limit float64
+	}
+
+	reading struct {
+		Sensor
+		v float64
+	}
+)
+
+// ParseSensor parses id[:label[:limit]], like coretemp/temp1:CPU:85.
+func ParseSensor(s string) (Sensor, error) {
+	parts := strings.SplitN(s, ":", 3)
+	sn := Sensor{ID: parts[0]}
+	if len(parts) > 1 {
+		sn.Label = parts[1]
+	}
+	if len(parts) > 2 {
+		limit, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			return sn, fmt.Errorf("invalid sensor limit %q", parts[2])
+		}
+		sn.Limit = limit
+	}
+	if !strings.Contains(sn.ID, "/") {
+		return sn, fmt.Errorf("invalid sensor %q, expected chip/input", sn.ID)
+	}
+	return sn, nil
+}
+
+func (h *HWMon) Name() string {
+	return "Sensors"
+}
+
+func (h *HWMon) Interval() time.Duration {
+	return h.PollInterval
+}
+
+// Poll the sensors, one per line.
+func (h *HWMon) Poll(ctx context.Context) ([]string, error) {
+	readings := h.read()
+	lines := make([]string, 0, len(readings))
+	h.alerts = nil
+	for _, r := range readings {
+		lines = append(lines, r.String())
+		if r.exceeded() {
+			h.alerts = append(h.alerts, Toast{
+				ID:       "hwmon/" + r.ID,
+				Lines:    []string{"!" + r.Label, r.value() + fmt.Sprintf(" limit %g", r.Limit)},
+				Priority: PriorityHigh,
+				Flash:    true,
+			})
+		}
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("no sensors found")
+	}
+	return lines, nil
+}
+
+// Alerts of the sensors past their limit.
+func (h *HWMon) Alerts() []Toast {
+	return h.alerts
+}
+
+// read the configured sensors or all if none are.
+func (h *HWMon) read() []reading {
+	all := h.scan()
+	if len(h.Sensors) == 0 {
+		return all
+	}
+	var res []reading
+	for _, s := range h.Sensors {
+		for _, r := range all {
+			if r.ID == s.ID {
+				if s.Label != "" {
+					r.Label = s.Label
+				}
+				r.Limit = s.Limit
+				res = append(res, r)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func (h *HWMon) scan() []reading {
+	root := h.Root
+	if root == "" {
+		root = "/sys/class/hwmon"
+	}
+	chips, _ := filepath.Glob(filepath.Join(root, "hwmon*"))
+	sort.Strings(chips)
+	var res []reading
+	for _, chip := range chips {
+		name := readString(filepath.Join(chip, "name"))
+		inputs, _ := filepath.Glob(filepath.Join(chip, "*_input"))
+		sort.Strings(inputs)
+		for _, in := range inputs {
+			input := strings.TrimSuffix(filepath.Base(in), "_input")
+			if !strings.HasPrefix(input, "temp") && !strings.HasPrefix(input, "fan") {
+				continue
+			}
+			v, err := strconv.ParseFloat(readString(in), 64)
+			if err != nil {
+				continue
+			}
+			if strings.HasPrefix(input, "temp") {
+				// millidegree Celsius
+				v /= 1000
+			}
+			id := name + "/" + input
+			label := readString(filepath.Join(chip, input+"_label"))
+			if label == "" {
+				label = id
+			}
+			res = append(res, reading{Sensor: Sensor{ID: id, Label: label}, v: v})
+		}
+	}
+	return res
+}
+
+func (r reading) fan() bool {
+	return strings.HasPrefix(r.ID[strings.LastIndexByte(r.ID, '/')+1:], "fan")
+}
+
+func (r reading) exceeded() bool {
+	if r.Limit == 0 {
+		return false
+	}
+	if r.fan() {
+		return r.v < r.Limit
+	}
+	return r.v > r.Limit
+}
+
+func (r reading) value() string {
+	if r.fan() {
+		return fmt.Sprintf("%.0frpm", r.v)
+	}
+	return fmt.Sprintf("%.0fC", r.v)
+}
+
+// String fits label and value on a line.
+func (r reading) String() string {
+	v := r.value()
+	label := r.Label
+	if max := 16 - len(v) - 1; len(label) > max {
+		label = label[:max]
+	}
+	return fmt.Sprintf("%-*s %s", 16-len(v)-1, label, v)
+}
+
+func readString(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
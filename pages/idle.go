@@ -0,0 +1,80 @@
+package pages
+
+import (
+	"github.com/artvel/display"
+	"time"
+)
+
+// Animation is played by the Board while there are neither pages nor
+// toasts, so an idle appliance still looks alive.
+type Animation struct {
+	// Frames are the lines of every step.
+	Frames [][]string
+	// Interval between two frames, defaults to a second.
+	Interval time.Duration
+}
+
+// Spinner is a small animation in the corner of the display.
+var Spinner = Animation{
+	Frames: [][]string{
+		{"", "               |"},
+		{"", "               /"},
+		{"", "               -"},
+		{"", "               \\"},
+	},
+	Interval: 250 * time.Millisecond,
+}
+
+// SetIdle sets the animation played while the board is empty, nil
+// removes it. It stops as soon as a page or toast is set and pauses for
+// an interval of the board once a button is pressed or a page or toast
+// was set, so it doesn't replace them right after they are gone.
+func (b *Board) SetIdle(a *Animation) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.idle = a
+	b.frame = 0
+	b.notify()
+}
+
+func (a *Animation) interval() time.Duration {
+	if a.Interval > 0 {
+		return a.Interval
+	}
+	return time.Second
+}
+
+// idleInterval of the animation, zero if there is none.
+func (b *Board) idleInterval() time.Duration {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.idle == nil || len(b.idle.Frames) == 0 {
+		return 0
+	}
+	return b.idle.interval()
+}
+
+// pressed stops the animation until the board interval passed.
+func (b *Board) pressed(ev display.ButtonEvent) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.quiet()
+	b.notify()
+}
+
+// quiet stops the animation until the board interval passed, the lock
+// must be held.
+func (b *Board) quiet() {
+	b.quietUntil = b.clock.Now().Add(b.interval)
+}
+
+// idleFrame to show if nothing else is, nil if there is none.
+func (b *Board) idleFrame(now time.Time) []string {
+	if b.idle == nil || len(b.idle.Frames) == 0 || now.Before(b.quietUntil) {
+		return nil
+	}
+	return b.idle.Frames[b.frame%len(b.idle.Frames)]
+}
@@ -0,0 +1,27 @@
+package pages
+
+import (
+	"github.com/artvel/display"
+	"testing"
+	"time"
+)
+
+func TestIdlePausesAfterWrites(t *testing.T) {
+	b := NewBoard(display.DummyLCD, time.Minute)
+	b.SetIdle(&Spinner)
+	if b.current(time.Now()) == nil {
+		t.Fatal("empty board doesn't play the animation")
+	}
+	b.Set("status", "ok")
+	b.Remove("status")
+	if lines := b.current(time.Now()); lines != nil {
+		t.Errorf("animation replaced a page right away: %q", lines)
+	}
+	b.Show(Toast{Lines: []string{"hi"}, Duration: time.Second})
+	if lines := b.current(time.Now().Add(2 * time.Second)); lines != nil {
+		t.Errorf("animation replaced a toast right away: %q", lines)
+	}
+	if b.current(time.Now().Add(2*time.Minute)) == nil {
+		t.Error("animation didn't resume")
+	}
+}
@@ -0,0 +1,134 @@
+package pages
+
+import (
+	"context"
+	"fmt"
+	"github.com/artvel/display"
+	"strings"
+	"time"
+)
+
+type (
+	// IPMI shows the power supplies and chassis temperatures reported
+	// by the BMC of rackmount boards through ipmitool. Sensors in a
+	// state other than ok raise a toast.
+	IPMI struct {
+		// Command and arguments to run ipmitool, like
+		// ipmitool -I lanplus -H bmc -U admin -E. Defaults to ipmitool.
+		Command []string
+		// PollInterval defaults to DefaultPollInterval.
+		PollInterval time.Duration
+
+		alerts []Toast
+	}
+
+	// ipmiSensor is a line of ipmitool sdr type.
+	ipmiSensor struct {
+		name, status, reading string
+	}
+)
+
+// the sensor types shown, in this order
+var ipmiTypes = []string{"Power Supply", "Temperature"}
+
+func (i *IPMI) Name() string {
+	return "IPMI"
+}
+
+func (i *IPMI) Interval() time.Duration {
+	return i.PollInterval
+}
+
+// Poll the sensors of the BMC, one per line.
+func (i *IPMI) Poll(ctx context.Context) ([]string, error) {
+	var (
+		lines  []string
+		failed error
+	)
+	i.alerts = nil
+	for _, typ := range ipmiTypes {
+		sensors, err := i.sdr(ctx, typ)
+		if err != nil {
+			failed = err
+			lines = append(lines, "IPMI "+strings.ToLower(typ), display.Tr(display.MsgUnavailable))
+			continue
+		}
+		for _, s := range sensors {
+			lines = append(lines, s.String())
+			if !s.ok() {
+				i.alerts = append(i.alerts, Toast{
+					ID:       "ipmi/" + s.name,
+					Lines:    []string{"!" + s.name, s.status + " " + s.reading},
+					Priority: PriorityHigh,
+				})
+			}
+		}
+	}
+	if failed != nil && len(lines) == 2*len(ipmiTypes) {
+		// nothing worked at all
+		return nil, failed
+	}
+	return lines, nil
+}
+
+// Alerts of the sensors not ok.
+func (i *IPMI) Alerts() []Toast {
+	return i.alerts
+}
+
+// sdr lists the sensors of typ.
+func (i *IPMI) sdr(ctx context.Context, typ string) ([]ipmiSensor, error) {
+	cmd := i.Command
+	if len(cmd) == 0 {
+		cmd = []string{"ipmitool"}
+	}
+	out, err := command(ctx, cmd, "sdr", "type", typ)
+	if err != nil {
+		return nil, err
+	}
+	return parseSDR(string(out)), nil
+}
+
+// parseSDR parses lines like
+// PS1 Status | C8h | ok | 10.1 | Presence detected
+func parseSDR(out string) []ipmiSensor {
+	var res []ipmiSensor
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Split(line, "|")
+		if len(f) < 5 {
+			continue
+		}
+		s := ipmiSensor{
+			name:    strings.TrimSpace(f[0]),
+			status:  strings.TrimSpace(f[2]),
+			reading: strings.TrimSpace(f[4]),
+		}
+		// ns means no reading, like an empty slot
+		if s.status == "ns" {
+			continue
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func (s ipmiSensor) ok() bool {
+	return s.status == "ok"
+}
+
+// String fits name and reading on a line, temperatures are shortened
+// to 45C and other sensors show their state.
+func (s ipmiSensor) String() string {
+	v := s.status
+	if s.ok() && strings.HasSuffix(s.reading, " degrees C") {
+		v = strings.TrimSuffix(s.reading, " degrees C") + "C"
+	}
+	if len(v) > 8 {
+		v = v[:8]
+	}
+	name := s.name
+	if max := 16 - len(v) - 1; len(name) > max {
+		name = name[:max]
+	}
+	return fmt.Sprintf("%-*s %s", 16-len(v)-1, name, v)
+}
@@ -0,0 +1,118 @@
+package pages
+
+import (
+	"context"
+	"fmt"
+	"github.com/artvel/display"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NTP shows if the clock is synchronized and its offset as reported
+// by chronyc or, if chrony isn't installed, ntpq of ntpd.
+type NTP struct {
+	// PollInterval defaults to DefaultPollInterval.
+	PollInterval time.Duration
+}
+
+// ntpState of the local time daemon
+type ntpState struct {
+	synced bool
+	offset time.Duration
+}
+
+func (n *NTP) Name() string {
+	return "NTP"
+}
+
+func (n *NTP) Interval() time.Duration {
+	return n.PollInterval
+}
+
+func (n *NTP) Poll(ctx context.Context) ([]string, error) {
+	st, err := chronyState(ctx)
+	if err != nil {
+		st, err = ntpqState(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return st.lines(), nil
+}
+
+func (s ntpState) lines() []string {
+	first := display.Tr(display.MsgNTPSynced)
+	if !s.synced {
+		first = display.Tr(display.MsgNTPNotSynced)
+	}
+	return []string{first, "offset " + formatOffset(s.offset)}
+}
+
+// chronyState parses the CSV of chronyc -c tracking, the fifth field
+// is the offset in seconds and the last the leap status.
+func chronyState(ctx context.Context) (ntpState, error) {
+	out, err := command(ctx, []string{"chronyc"}, "-c", "tracking")
+	if err != nil {
+		return ntpState{}, err
+	}
+	f := strings.Split(strings.TrimSpace(string(out)), ",")
+	if len(f) < 14 {
+		return ntpState{}, fmt.Errorf("chronyc: unexpected output %q", out)
+	}
+	sec, err := strconv.ParseFloat(f[4], 64)
+	if err != nil {
+		return ntpState{}, err
+	}
+	return ntpState{
+		synced: f[13] != "Not synchronised",
+		offset: time.Duration(sec * float64(time.Second)),
+	}, nil
+}
+
+// ntpqState parses the system variables of ntpq -c rv, like
+// status=0615 leap_none, sync_ntp, ... offset=-0.123 in milliseconds.
+func ntpqState(ctx context.Context) (ntpState, error) {
+	out, err := command(ctx, []string{"ntpq"}, "-c", "rv 0 offset")
+	if err != nil {
+		return ntpState{}, err
+	}
+	var st ntpState
+	found := false
+	for _, kv := range strings.FieldsFunc(string(out), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n'
+	}) {
+		switch {
+		case strings.HasPrefix(kv, "offset="):
+			ms, err := strconv.ParseFloat(strings.TrimPrefix(kv, "offset="), 64)
+			if err != nil {
+				return st, err
+			}
+			st.offset = time.Duration(ms * float64(time.Millisecond))
+			found = true
+		case kv == "leap_alarm":
+			st.synced = false
+		case strings.HasPrefix(kv, "sync_") && kv != "sync_unspec" && kv != "sync_alarm":
+			st.synced = true
+		}
+	}
+	if !found {
+		return st, fmt.Errorf("ntpq: unexpected output %q", out)
+	}
+	return st, nil
+}
+
+// formatOffset like +1.25ms with the sign.
+func formatOffset(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%s%.2fs", sign, d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%s%.2fms", sign, float64(d)/float64(time.Millisecond))
+	}
+	return fmt.Sprintf("%s%dus", sign, d.Microseconds())
+}
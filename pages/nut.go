@@ -0,0 +1,166 @@
+package pages
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/artvel/display"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NUT shows the state of a UPS served by upsd of the Network UPS Tools
+// and raises a critical toast while the UPS is on battery.
+type NUT struct {
+	// UPS name as configured in ups.conf.
+	UPS string
+	// Addr of upsd, defaults to localhost:3493.
+	Addr string
+	// PollInterval defaults to DefaultPollInterval.
+	PollInterval time.Duration
+
+	alerts []Toast
+}
+
+// ParseNUT parses the upsc notation upsname[@hostname[:port]].
+func ParseNUT(s string) *NUT {
+	n := &NUT{UPS: s}
+	if i := strings.IndexByte(s, '@'); i >= 0 {
+		n.UPS, n.Addr = s[:i], s[i+1:]
+		if _, _, err := net.SplitHostPort(n.Addr); err != nil {
+			n.Addr = net.JoinHostPort(n.Addr, "3493")
+		}
+	}
+	return n
+}
+
+func (n *NUT) Name() string {
+	return "UPS " + n.UPS
+}
+
+func (n *NUT) Interval() time.Duration {
+	return n.PollInterval
+}
+
+func (n *NUT) Poll(ctx context.Context) ([]string, error) {
+	vars, err := n.vars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n.alerts = nil
+	if onBattery(vars) {
+		n.alerts = []Toast{{
+			ID:       "nut/" + n.UPS,
+			Lines:    []string{"!UPS ON BATTERY", nutRemaining(vars)},
+			Priority: PriorityCritical,
+		}}
+	}
+	return nutLines(vars), nil
+}
+
+// Alerts while the UPS is on battery.
+func (n *NUT) Alerts() []Toast {
+	return n.alerts
+}
+
+// vars of the UPS as reported by LIST VAR.
+func (n *NUT) vars(ctx context.Context) (map[string]string, error) {
+	addr := n.Addr
+	if addr == "" {
+		addr = "localhost:3493"
+	}
+	var d net.Dialer
+	con, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer con.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = con.SetDeadline(deadline)
+	} else {
+		_ = con.SetDeadline(time.Now().Add(5 * time.Second))
+	}
+	if _, err = fmt.Fprintf(con, "LIST VAR %s\n", n.UPS); err != nil {
+		return nil, err
+	}
+	vars := map[string]string{}
+	s := bufio.NewScanner(con)
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.HasPrefix(line, "ERR "):
+			return nil, fmt.Errorf("upsd: %s", strings.TrimPrefix(line, "ERR "))
+		case strings.HasPrefix(line, "END LIST VAR"):
+			_, _ = fmt.Fprint(con, "LOGOUT\n")
+			return vars, nil
+		case strings.HasPrefix(line, "VAR "):
+			// VAR <ups> <name> "<value>"
+			parts := strings.SplitN(line, " ", 4)
+			if len(parts) == 4 {
+				v, err := strconv.Unquote(parts[3])
+				if err != nil {
+					v = strings.Trim(parts[3], `"`)
+				}
+				vars[parts[2]] = v
+			}
+		}
+	}
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("upsd: unexpected end of list")
+}
+
+func onBattery(vars map[string]string) bool {
+	for _, f := range strings.Fields(vars["ups.status"]) {
+		if f == "OB" {
+			return true
+		}
+	}
+	return false
+}
+
+// nutLines show the state and runtime and the charge as progress bar.
+func nutLines(vars map[string]string) []string {
+	state := "UPS"
+	for _, f := range strings.Fields(vars["ups.status"]) {
+		switch f {
+		case "OL":
+			state += " online"
+		case "OB":
+			state += " battery"
+		case "LB":
+			state += " low"
+		case "CHRG":
+			state += " chrg"
+		}
+	}
+	if rt := nutRuntime(vars); rt != "" {
+		state += " " + rt
+	}
+	charge, _ := strconv.Atoi(strings.SplitN(vars["battery.charge"], ".", 2)[0])
+	return []string{state, display.Progress(charge)}
+}
+
+func nutRemaining(vars map[string]string) string {
+	res := vars["battery.charge"] + "%"
+	if rt := nutRuntime(vars); rt != "" {
+		res += " " + rt + " left"
+	}
+	return res
+}
+
+// nutRuntime formats battery.runtime like 1h05m.
+func nutRuntime(vars map[string]string) string {
+	sec, err := strconv.Atoi(strings.SplitN(vars["battery.runtime"], ".", 2)[0])
+	if err != nil {
+		return ""
+	}
+	d := time.Duration(sec) * time.Second
+	if d >= time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
@@ -0,0 +1,175 @@
+package pages
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type (
+	// Plugin is a source backed by an external program, so pages can be
+	// written in any language without rebuilding the daemon. The program
+	// is started on the first poll and answers every request line on its
+	// stdin with a response line on its stdout, both JSON:
+	//
+	//	> {"method":"poll"}
+	//	< {"lines":["Backup","ok 2h ago"]}
+	//	> {"method":"poll"}
+	//	< {"lines":["Backup","failed"],"alerts":[{"id":"failed","lines":["!Backup","failed"],"priority":10}]}
+	//
+	// A response with an error, like {"error":"no data"}, is shown as
+	// unavailable. The priority of alerts is the one of the Toast. The
+	// program is restarted if it exits or doesn't answer in time and
+	// should exit once its stdin is closed. Its stderr is passed on.
+	Plugin struct {
+		// Command and its arguments.
+		Command []string
+		// PollInterval defaults to DefaultPollInterval.
+		PollInterval time.Duration
+
+		m      sync.Mutex
+		proc   *pluginProc
+		alerts []Toast
+	}
+
+	pluginProc struct {
+		cmd *exec.Cmd
+		in  io.WriteCloser
+		out *bufio.Reader
+	}
+
+	pluginResponse struct {
+		Lines  []string      `json:"lines"`
+		Error  string        `json:"error"`
+		Alerts []pluginAlert `json:"alerts"`
+	}
+
+	pluginAlert struct {
+		ID       string   `json:"id"`
+		Lines    []string `json:"lines"`
+		Priority Priority `json:"priority"`
+		Flash    bool     `json:"flash"`
+	}
+)
+
+var pluginRequest = []byte(`{"method":"poll"}` + "\n")
+
+// Name is the file name of the program.
+func (p *Plugin) Name() string {
+	if len(p.Command) == 0 {
+		return "plugin"
+	}
+	return filepath.Base(p.Command[0])
+}
+
+func (p *Plugin) Interval() time.Duration {
+	return p.PollInterval
+}
+
+func (p *Plugin) Poll(ctx context.Context) ([]string, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.proc == nil {
+		if err := p.start(); err != nil {
+			return nil, err
+		}
+	}
+	proc := p.proc
+	if _, err := proc.in.Write(pluginRequest); err != nil {
+		p.stop()
+		return nil, err
+	}
+	type result struct {
+		line []byte
+		err  error
+	}
+	c := make(chan result, 1)
+	go func() {
+		line, err := proc.out.ReadBytes('\n')
+		c <- result{line, err}
+	}()
+	var r result
+	select {
+	case r = <-c:
+	case <-ctx.Done():
+		// a late answer would be taken for the next one
+		p.stop()
+		return nil, ctx.Err()
+	}
+	if r.err != nil {
+		p.stop()
+		return nil, r.err
+	}
+	var res pluginResponse
+	if err := json.Unmarshal(r.line, &res); err != nil {
+		return nil, err
+	}
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+	p.alerts = nil
+	for _, a := range res.Alerts {
+		p.alerts = append(p.alerts, Toast{
+			ID:       "plugin/" + p.Name() + "/" + a.ID,
+			Lines:    a.Lines,
+			Priority: a.Priority,
+			Flash:    a.Flash,
+		})
+	}
+	return res.Lines, nil
+}
+
+func (p *Plugin) Alerts() []Toast {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.alerts
+}
+
+// Close stops the program.
+func (p *Plugin) Close() error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.stop()
+	return nil
+}
+
+func (p *Plugin) start() error {
+	if len(p.Command) == 0 {
+		return errors.New("plugin without command")
+	}
+	cmd := exec.Command(p.Command[0], p.Command[1:]...)
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	p.proc = &pluginProc{cmd: cmd, in: in, out: bufio.NewReader(out)}
+	return nil
+}
+
+func (p *Plugin) stop() {
+	if p.proc == nil {
+		return
+	}
+	_ = p.proc.in.Close()
+	_ = p.proc.cmd.Process.Kill()
+	_ = p.proc.cmd.Wait()
+	p.proc = nil
+}
@@ -0,0 +1,161 @@
+package pages
+
+import (
+	"context"
+	"fmt"
+	"github.com/artvel/display"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+type (
+	// DataSource provides the lines of a status page. More lines than
+	// the display has are shown on several pages.
+	DataSource interface {
+		// Name of the source, shown if polling fails.
+		Name() string
+		// Poll the current lines.
+		Poll(ctx context.Context) ([]string, error)
+		// Interval between two polls, zero means DefaultPollInterval.
+		Interval() time.Duration
+	}
+
+	// Alerting is implemented by sources raising toasts. Alerts is
+	// called after every successful Poll and returns the toasts which
+	// are currently due, the ones not returned anymore are dismissed.
+	Alerting interface {
+		Alerts() []Toast
+	}
+
+	// Scheduler polls its sources and shows their lines on a Board.
+	// The first polls are staggered so the sources don't all run at
+	// the same time. It uses the clock of the board.
+	Scheduler struct {
+		board   *Board
+		m       sync.Mutex
+		sources []DataSource
+	}
+
+	// paged keeps the pages of a source with a varying number of lines.
+	paged struct {
+		prefix string
+		count  int
+	}
+	// alerts keeps the toasts a source raised to dismiss them once
+	// they aren't reported anymore.
+	alerts map[string]bool
+)
+
+// DefaultPollInterval of the sources
+const DefaultPollInterval = 10 * time.Second
+
+// NewScheduler shows sources on b.
+func NewScheduler(b *Board, sources ...DataSource) *Scheduler {
+	return &Scheduler{board: b, sources: sources}
+}
+
+// Add a source, it is polled on the next Run.
+func (s *Scheduler) Add(src DataSource) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.sources = append(s.sources, src)
+}
+
+// Run polls the sources until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.m.Lock()
+	sources := append([]DataSource{}, s.sources...)
+	s.m.Unlock()
+
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func(i int, src DataSource) {
+			defer wg.Done()
+			interval := src.Interval()
+			if interval <= 0 {
+				interval = DefaultPollInterval
+			}
+			// spread the first polls over the interval
+			delay := interval * time.Duration(i) / time.Duration(len(sources))
+			s.run(ctx, src, interval, delay)
+		}(i, src)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (s *Scheduler) run(ctx context.Context, src DataSource, interval, delay time.Duration) {
+	pages := paged{prefix: src.Name()}
+	var active alerts
+	select {
+	case <-ctx.Done():
+		return
+	case <-s.board.clock.After(delay):
+	}
+	for {
+		// a poll may not take longer than the interval
+		pctx, cancel := context.WithTimeout(ctx, interval)
+		lines, err := src.Poll(pctx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("pages: %s: %v", src.Name(), err)
+			lines = []string{src.Name(), display.Tr(display.MsgUnavailable)}
+		} else if a, ok := src.(Alerting); ok {
+			active.update(s.board, a.Alerts())
+		}
+		pages.set(s.board, lines)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.board.clock.After(interval):
+		}
+	}
+}
+
+// set lines on as many pages as needed and removes the left overs.
+func (p *paged) set(b *Board, lines []string) {
+	lineCount := display.LineCountOf(b.lcd)
+	n := 0
+	for ; n*lineCount < len(lines); n++ {
+		end := (n + 1) * lineCount
+		if end > len(lines) {
+			end = len(lines)
+		}
+		b.Set(fmt.Sprintf("%s/%d", p.prefix, n), lines[n*lineCount:end]...)
+	}
+	for ; p.count > n; p.count-- {
+		b.Remove(fmt.Sprintf("%s/%d", p.prefix, p.count-1))
+	}
+	p.count = n
+}
+
+// update shows the toasts and dismisses the ones raised before
+// but not anymore.
+func (a *alerts) update(b *Board, toasts []Toast) {
+	firing := alerts{}
+	for _, t := range toasts {
+		firing[t.ID] = true
+		b.Show(t)
+	}
+	for id := range *a {
+		if !firing[id] {
+			b.Dismiss(id)
+		}
+	}
+	*a = firing
+}
+
+// command runs cmd with the additional args and returns its output.
+// cmd may contain arguments itself, like ipmitool -H bmc.
+func command(ctx context.Context, cmd []string, args ...string) ([]byte, error) {
+	args = append(append([]string{}, cmd[1:]...), args...)
+	return exec.CommandContext(ctx, cmd[0], args...).Output()
+}
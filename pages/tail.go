@@ -0,0 +1,109 @@
+package pages
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Tail shows the latest line of a log file matching Pattern, for
+// example the last error of a backup job. Lines longer than the
+// display scroll. Rotated or truncated files are read from the start.
+type Tail struct {
+	Path string
+	// Pattern filters the lines, nil matches all of them.
+	Pattern *regexp.Regexp
+	// Title is the first line, defaults to the file name.
+	Title string
+	// PollInterval is also the scroll speed, defaults to a second.
+	PollInterval time.Duration
+
+	offset int64
+	last   string
+	scroll int
+}
+
+const (
+	// tailWindow is read at the first poll to find the latest line
+	tailWindow = 64 << 10
+	// the display width
+	tailWidth = 16
+	// scrolled per poll
+	tailStep = 4
+)
+
+func (t *Tail) Name() string {
+	if t.Title != "" {
+		return t.Title
+	}
+	return filepath.Base(t.Path)
+}
+
+func (t *Tail) Interval() time.Duration {
+	if t.PollInterval > 0 {
+		return t.PollInterval
+	}
+	return time.Second
+}
+
+func (t *Tail) Poll(ctx context.Context) ([]string, error) {
+	f, err := os.Open(t.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case fi.Size() < t.offset:
+		// rotated or truncated
+		t.offset = 0
+	case t.offset == 0 && fi.Size() > tailWindow:
+		t.offset = fi.Size() - tailWindow
+	}
+	if _, err = f.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	// keep an incomplete last line for the next poll
+	if end := strings.LastIndexByte(string(b), '\n'); end >= 0 {
+		t.offset += int64(end + 1)
+		t.match(string(b[:end]))
+	}
+	return []string{t.Name(), t.visible()}, nil
+}
+
+// match remembers the latest matching line of s.
+func (t *Tail) match(s string) {
+	lines := strings.Split(s, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		l := strings.TrimRight(lines[i], "\r")
+		if l != "" && (t.Pattern == nil || t.Pattern.MatchString(l)) {
+			if l != t.last {
+				t.last, t.scroll = l, 0
+			}
+			return
+		}
+	}
+}
+
+// visible part of the latest line, it scrolls with every call.
+func (t *Tail) visible() string {
+	if len(t.last) <= tailWidth {
+		return t.last
+	}
+	// scroll through and start over with a gap
+	ring := t.last + "   "
+	start := t.scroll % len(ring)
+	t.scroll += tailStep
+	return (ring + ring)[start : start+tailWidth]
+}
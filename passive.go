@@ -0,0 +1,77 @@
+package display
+
+import (
+	"time"
+)
+
+// safeOrder of the passive probe. The QNAP handshake is two bytes at
+// 1200 baud, which the checksummed ASUSTOR parser discards. ASUSTOR
+// frames at 115200 baud however have been seen to confuse QNAP panels
+// until they were power cycled.
+var safeOrder = []string{"Qnap", "Asustor"}
+
+// WithPassiveProbe makes Find listen on the port with the settings of
+// every driver for the given time before sending anything. If a
+// driver recognizes the traffic, like a button report, only it is
+// probed. Otherwise the drivers are probed with their most benign
+// query in a safe order, QNAP first. Zero listens for 500ms per driver.
+func WithPassiveProbe(listen time.Duration) Option {
+	return func(o *options) {
+		o.passive = durationOr(listen, 500*time.Millisecond)
+	}
+}
+
+// probeOrder of the drivers for tty.
+func (o *options) probeOrder(tty string) []driver {
+	if o.passive <= 0 {
+		return drivers
+	}
+	var order []driver
+	for _, name := range safeOrder {
+		for _, d := range drivers {
+			if d.name == name {
+				order = append(order, d)
+			}
+		}
+	}
+	for _, d := range order {
+		if o.overhear(d, tty) {
+			return []driver{d}
+		}
+	}
+	return order
+}
+
+// overhear reports if a valid frame of d was received on tty without
+// sending anything.
+func (o *options) overhear(d driver, tty string) bool {
+	con, err := o.serialConnector(d.serial(tty))()
+	if err != nil {
+		return false
+	}
+	heard := make(chan bool, 1)
+	go func() {
+		parser := d.parser()
+		buf := make([]byte, 32)
+		for {
+			n, err := con.Read(buf)
+			if err != nil {
+				heard <- false
+				return
+			}
+			if len(parser.Feed(buf[:n])) > 0 {
+				heard <- true
+				return
+			}
+		}
+	}()
+	select {
+	case ok := <-heard:
+		_ = con.Close()
+		return ok
+	case <-o.clock.After(o.passive):
+		// unblocks the reader
+		_ = con.Close()
+		return false
+	}
+}
@@ -0,0 +1,133 @@
+package display
+
+import (
+	"context"
+	"errors"
+	"github.com/artvel/display/text"
+	"strings"
+)
+
+// ListPicker lets the user pick an item of a list with up and down,
+// longer lists scroll through the lines of the panel. Moving past
+// the last item wraps around to the first one and back.
+type ListPicker struct {
+	Items []string
+	// Selected is the preselected item, it is updated once one was
+	// picked.
+	Selected int
+	// Cursor marks the selected item, defaults to the one of the
+	// theme.
+	Cursor string
+	// More and Less are shown at the end of the last and first line
+	// if there are items below or above, default to the ones of the
+	// theme.
+	More string
+	Less string
+	// OnChange is called with every newly selected item, for example
+	// to preview it.
+	OnChange func(i int)
+	// Keymap defaults to the DefaultKeymap of the display.
+	Keymap Keymap
+}
+
+var ErrNoItems = errors.New("no items to pick from")
+
+// Run shows the list on lcd and blocks until an item was picked with
+// select, it returns its index, or canceled with back.
+func (p *ListPicker) Run(lcd LCD) (int, error) {
+	return p.RunContext(context.Background(), lcd)
+}
+
+// RunContext works like Run but returns ctx.Err() once ctx is done.
+func (p *ListPicker) RunContext(ctx context.Context, lcd LCD) (int, error) {
+	n := len(p.Items)
+	if n == 0 {
+		return -1, ErrNoItems
+	}
+	rows := 2
+	if fb, ok := FramebufferOf(lcd); ok {
+		rows = len(fb.Lines())
+	}
+	sel := p.Selected
+	if sel < 0 || sel >= n {
+		sel = 0
+	}
+	top := 0
+	width := WidthOf(lcd)
+	render := func() error {
+		// keep the selection in the viewport
+		if sel < top {
+			top = sel
+		} else if sel >= top+rows {
+			top = sel - rows + 1
+		}
+		for row := 0; row < rows; row++ {
+			if err := lcd.Write(Line(row), p.line(top+row, sel, top, rows, width)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := render(); err != nil {
+		return sel, err
+	}
+	var err error
+	done := false
+	ListenKeysContext(ctx, lcd, p.Keymap, func(key Key, ev ButtonEvent) bool {
+		switch key {
+		case KeySelect:
+			done = true
+			return false
+		case KeyBack:
+			err, done = ErrCanceled, true
+			return false
+		case KeyUp:
+			sel = (sel + n - 1) % n
+		case KeyDown:
+			sel = (sel + 1) % n
+		default:
+			return true
+		}
+		if p.OnChange != nil {
+			p.OnChange(sel)
+		}
+		err = render()
+		return err == nil
+	})
+	switch {
+	case err != nil:
+	case ctx.Err() != nil:
+		err = ctx.Err()
+	case !done:
+		err = ErrClosed
+	default:
+		p.Selected = sel
+	}
+	return sel, err
+}
+
+// line i of the list with the cursor and the scroll indicators, empty
+// past the end of the list.
+func (p *ListPicker) line(i, sel, top, rows, width int) string {
+	if i >= len(p.Items) {
+		return ""
+	}
+	t := CurrentTheme()
+	cursor := stringOr(p.Cursor, t.Cursor)
+	mark := strings.Repeat(" ", len(cursor))
+	if i == sel {
+		mark = cursor
+	}
+	indicator := ""
+	switch {
+	case i == top && top > 0:
+		indicator = stringOr(p.Less, t.Less)
+	case i == top+rows-1 && i < len(p.Items)-1:
+		indicator = stringOr(p.More, t.More)
+	}
+	txt := mark + p.Items[i]
+	if indicator == "" {
+		return txt
+	}
+	return text.Fit(txt, width-len(indicator)) + indicator
+}
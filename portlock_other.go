@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package display
+
+// lockPort is a no-op, the ports are opened exclusively on windows.
+func lockPort(name string) (unlock func(), err error) {
+	return func() {}, nil
+}
@@ -0,0 +1,83 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package display
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// the directories of UUCP style lock files, the first existing is used
+var lockDirs = []string{"/var/lock", "/run/lock"}
+
+// lockPort takes an advisory lock for the serial port name. It uses a
+// UUCP lock file like LCK..ttyS1, which minicom and friends honor, and
+// flocks it. Without a writable lock directory the device node itself
+// is flocked. ErrPortBusy is returned if somebody else holds the lock.
+func lockPort(name string) (unlock func(), err error) {
+	for _, dir := range lockDirs {
+		if st, err := os.Stat(dir); err != nil || !st.IsDir() {
+			continue
+		}
+		unlock, err = lockFile(filepath.Join(dir, "LCK.."+filepath.Base(name)), true)
+		if err == nil || err == ErrPortBusy {
+			return unlock, err
+		}
+	}
+	return lockFile(name, false)
+}
+
+func lockFile(path string, uucp bool) (func(), error) {
+	flags := os.O_RDONLY | syscall.O_NONBLOCK
+	if uucp {
+		if pid := lockOwner(path); pid != 0 && pid != os.Getpid() {
+			return nil, ErrPortBusy
+		}
+		flags = os.O_RDWR | os.O_CREATE
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrPortBusy
+		}
+		return nil, err
+	}
+	if uucp {
+		_ = f.Truncate(0)
+		_, _ = fmt.Fprintf(f, "%10d\n", os.Getpid())
+	}
+	return func() {
+		if uucp {
+			_ = os.Remove(path)
+		}
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+// lockOwner returns the pid of a living process in the lock file.
+// Stale files of crashed processes are ignored.
+func lockOwner(path string) int {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	if err = syscall.Kill(pid, 0); err != nil && err != syscall.EPERM {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,42 @@
+package display
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// DefaultTTy is the usual name of USB serial adapters on macOS, Find
+// scans all ports though.
+const DefaultTTy = "/dev/cu.usbserial"
+
+// the adapter names vary, Find scans all ports
+const scanPorts = true
+
+// Ports lists the callout devices of the system like
+// /dev/cu.usbserial-A1, without the Bluetooth ones.
+func Ports() ([]string, error) {
+	m, err := filepath.Glob("/dev/cu.*")
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, p := range m {
+		if !strings.Contains(p, "Bluetooth") {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+// normalizePort accepts names without /dev/ and uses the callout
+// device for /dev/tty.*, opening the dial-in device blocks until the
+// carrier is detected.
+func normalizePort(name string) string {
+	if name != "" && !strings.HasPrefix(name, "/") {
+		name = "/dev/" + name
+	}
+	if strings.HasPrefix(name, "/dev/tty.") {
+		return "/dev/cu." + strings.TrimPrefix(name, "/dev/tty.")
+	}
+	return name
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!windows,!freebsd,!netbsd,!openbsd
+
+package display
+
+// DefaultTTy is the port of the panel on the supported NAS models.
+const DefaultTTy = "/dev/ttyS1"
+
+const scanPorts = false
+
+// Ports isn't supported on this platform.
+func Ports() ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+func normalizePort(name string) string {
+	return name
+}
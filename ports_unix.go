@@ -0,0 +1,43 @@
+//go:build linux || freebsd || netbsd || openbsd
+// +build linux freebsd netbsd openbsd
+
+package display
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// DefaultTTy is the port of the panel on the supported NAS models.
+const DefaultTTy = "/dev/ttyS1"
+
+// the NAS panels are at a fixed port, Find doesn't scan
+const scanPorts = false
+
+// the serial devices of Linux and the BSDs
+var portPatterns = []string{
+	"/dev/ttyS*", "/dev/ttyUSB*", "/dev/ttyACM*", "/dev/ttyAMA*",
+	"/dev/cuau*", "/dev/cuaU*",
+}
+
+// Ports lists the serial ports of the system, like /dev/ttyS0 or
+// /dev/ttyUSB0.
+func Ports() ([]string, error) {
+	var res []string
+	for _, p := range portPatterns {
+		m, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, m...)
+	}
+	return res, nil
+}
+
+// normalizePort accepts names without /dev/ like ttyUSB0.
+func normalizePort(name string) string {
+	if name != "" && !strings.HasPrefix(name, "/") {
+		return "/dev/" + name
+	}
+	return name
+}
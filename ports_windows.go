@@ -0,0 +1,50 @@
+package display
+
+import (
+	"sort"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// DefaultTTy is the first COM port, Find scans all ports though.
+const DefaultTTy = "COM1"
+
+// USB adapters get varying numbers, Find scans all ports
+const scanPorts = true
+
+var procQueryDosDevice = syscall.NewLazyDLL("kernel32.dll").NewProc("QueryDosDeviceW")
+
+// Ports lists the COM ports of the system in numeric order.
+func Ports() ([]string, error) {
+	buf := make([]uint16, 1<<16)
+	n, _, err := procQueryDosDevice.Call(0, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if n == 0 {
+		return nil, err
+	}
+	var res []string
+	for _, name := range strings.Split(string(utf16.Decode(buf[:n])), "\x00") {
+		if strings.HasPrefix(name, "COM") {
+			res = append(res, name)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		// COM2 before COM10
+		if len(res[i]) != len(res[j]) {
+			return len(res[i]) < len(res[j])
+		}
+		return res[i] < res[j]
+	})
+	return res, nil
+}
+
+// normalizePort accepts lower case names like com3 and the device
+// path \\.\COM10.
+func normalizePort(name string) string {
+	name = strings.TrimPrefix(name, `\\.\`)
+	if len(name) > 3 && strings.EqualFold(name[:3], "com") {
+		return "COM" + name[3:]
+	}
+	return name
+}
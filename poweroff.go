@@ -0,0 +1,154 @@
+package display
+
+import (
+	"context"
+	"time"
+)
+
+// PowerOff is the front panel power off flow: holding a button starts
+// a confirmation and, once confirmed, a countdown which any button
+// cancels. Then Action shuts the machine down.
+type PowerOff struct {
+	// Button to hold, defaults to ButtonBoth.
+	Button Button
+	// Hold is how long the button has to be held, defaults to 3 seconds.
+	Hold time.Duration
+	// Prompt of the confirmation, defaults to the MsgPowerOff of the locale.
+	Prompt string
+	// Countdown after the confirmation, defaults to 5 seconds.
+	Countdown time.Duration
+	// Action shuts the machine down, like exec.Command("poweroff").Run.
+	Action func() error
+	// Started is called when the flow takes over the display, the
+	// returned func once it gives it back. Use it to pause whatever
+	// else writes to the display. Optional.
+	Started func() (done func())
+}
+
+// Watch blocks until ctx is done and runs the flow every time the button
+// is released after it was held long enough. The display has to report
+// the press when it happens, like the QNAP panels do. Displays which aren't Observable are listened to,
+// so other listeners don't get the events while Watch runs.
+// It returns the error of the Action.
+func (p PowerOff) Watch(ctx context.Context, lcd LCD) error {
+	hold := durationOr(p.Hold, 3*time.Second)
+	btn := p.Button
+	if btn == ButtonUnknown {
+		btn = ButtonBoth
+	}
+
+	held := make(chan struct{}, 1)
+	// the flow starts on release, otherwise the release would
+	// answer the confirmation right away
+	detect := func(ev ButtonEvent) {
+		if ev.Button == btn && ev.Released && ev.Held >= hold {
+			select {
+			case held <- struct{}{}:
+			default:
+			}
+		}
+	}
+	cancel, ok := ObserveButtons(lcd, detect)
+	defer cancel()
+	if !ok {
+		go ListenEventsContext(ctx, lcd, func(ev ButtonEvent) bool {
+			detect(ev)
+			return true
+		})
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-held:
+			done, err := p.Run(lcd)
+			if done || err != nil {
+				return err
+			}
+			// the button might have been held during the flow
+			select {
+			case <-held:
+			default:
+			}
+		}
+	}
+}
+
+// Run the confirmation and the countdown right away.
+// It reports if the Action was executed.
+func (p PowerOff) Run(lcd LCD) (bool, error) {
+	if p.Started != nil {
+		if done := p.Started(); done != nil {
+			defer done()
+		}
+	}
+	prompt := p.Prompt
+	if prompt == "" {
+		prompt = Tr(MsgPowerOff)
+	}
+	yes, err := Confirm(lcd, prompt)
+	if err != nil || !yes {
+		return false, err
+	}
+
+	prev, stateErr := SaveState(lcd)
+	if !p.countdown(lcd) {
+		if stateErr == nil {
+			_ = RestoreState(lcd, prev)
+		}
+		return false, nil
+	}
+	_ = lcd.Write(LineOne, Tr(MsgPoweringOff))
+	_ = lcd.Write(LineTwo, "")
+	if p.Action == nil {
+		return true, nil
+	}
+	if err = p.Action(); err != nil {
+		_ = lcd.Write(LineOne, Tr(MsgPowerOffFailed))
+		_ = lcd.Write(LineTwo, err.Error())
+		return true, err
+	}
+	return true, nil
+}
+
+// countdown reports false if it was canceled by a button.
+func (p PowerOff) countdown(lcd LCD) bool {
+	total := durationOr(p.Countdown, 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), total)
+	defer cancel()
+
+	canceled := make(chan struct{})
+	listening := make(chan struct{})
+	go func() {
+		defer close(listening)
+		ListenEventsContext(ctx, lcd, func(ev ButtonEvent) bool {
+			if ev.Released {
+				close(canceled)
+				return false
+			}
+			return true
+		})
+	}()
+	// give the buttons back before returning
+	defer func() {
+		cancel()
+		<-listening
+	}()
+
+	c := ClockOf(lcd)
+	start := c.Now()
+	for {
+		left := total - c.Now().Sub(start)
+		if left <= 0 {
+			return true
+		}
+		_ = lcd.Write(LineOne, Tr(MsgPowerOffIn, int((left+time.Second-1)/time.Second)))
+		_ = lcd.Write(LineTwo, Progress(int(100*left/total)))
+		select {
+		case <-canceled:
+			return false
+		case <-c.After(250 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package display
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Proxy forwards to a display which can be replaced at runtime, for
+// example to start with the DummyLCD and upgrade to the real driver
+// once the hardware shows up. The proxy keeps track of the content and
+// replays it on the new display, listeners and observers move along.
+type Proxy struct {
+	m   sync.Mutex
+	lcd LCD
+	fb  *Framebuffer
+	// swapped is closed and replaced on every Swap
+	swapped chan struct{}
+
+	om        sync.Mutex
+	observers map[int]func(ev ButtonEvent)
+	nextID    int
+	// cancels the button observation of the current display
+	unobserve func()
+}
+
+// NewProxy forwards to lcd until it is swapped.
+func NewProxy(lcd LCD) *Proxy {
+	p := &Proxy{
+		lcd:       lcd,
+		fb:        NewFramebuffer(LineCountOf(lcd)),
+		swapped:   make(chan struct{}),
+		observers: map[int]func(ev ButtonEvent){},
+	}
+	p.unobserve = p.observeButtons(lcd)
+	return p
+}
+
+// Swap the display and replay the current content on it, as far as
+// it fits. The previous display is closed.
+func (p *Proxy) Swap(lcd LCD) error {
+	p.m.Lock()
+	old := p.lcd
+	p.lcd = lcd
+	p.unobserve()
+	p.unobserve = p.observeButtons(lcd)
+	close(p.swapped)
+	p.swapped = make(chan struct{})
+	p.fb.resize(LineCountOf(lcd))
+	c := p.fb.Content()
+	p.m.Unlock()
+
+	_ = old.Close()
+	width := WidthOf(lcd)
+	for i, txt := range c.Lines {
+		if err := lcd.Write(Line(i), txt); err != nil {
+			return err
+		}
+		p.fb.Set(Line(i), fit(txt, width))
+	}
+	return lcd.Enable(c.Enabled)
+}
+
+// Current display the proxy forwards to.
+func (p *Proxy) Current() LCD {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.lcd
+}
+
+func (p *Proxy) Unwrap() LCD {
+	return p.Current()
+}
+
+func (p *Proxy) Open() error {
+	return p.Current().Open()
+}
+
+func (p *Proxy) Write(line Line, text string) error {
+	err := p.Current().Write(line, text)
+	if err == nil {
+		p.fb.Set(line, fit(text, WidthOf(p)))
+	}
+	return err
+}
+
+func (p *Proxy) Enable(yes bool) error {
+	err := p.Current().Enable(yes)
+	if err == nil {
+		p.fb.SetEnabled(yes)
+	}
+	return err
+}
+
+func (p *Proxy) Flush() error {
+	return flush(p.Current())
+}
+
+func (p *Proxy) Close() error {
+	return p.Current().Close()
+}
+
+func (p *Proxy) Listen(l func(btn int, released bool) bool) {
+	p.ListenEvents(func(ev ButtonEvent) bool {
+		return l(ev.Raw, ev.Released)
+	})
+}
+
+func (p *Proxy) ListenEvents(l func(ev ButtonEvent) bool) {
+	p.ListenEventsContext(context.Background(), l)
+}
+
+// ListenEventsContext listens on the current display and moves on to
+// the next one after a Swap.
+func (p *Proxy) ListenEventsContext(ctx context.Context, l func(ev ButtonEvent) bool) {
+	for {
+		p.m.Lock()
+		lcd, swapped := p.lcd, p.swapped
+		p.m.Unlock()
+
+		var stopped int32
+		lctx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-swapped:
+				cancel()
+			case <-lctx.Done():
+			}
+		}()
+		ListenEventsContext(lctx, lcd, func(ev ButtonEvent) bool {
+			if !l(ev) {
+				atomic.StoreInt32(&stopped, 1)
+				return false
+			}
+			return true
+		})
+		cancel()
+		if atomic.LoadInt32(&stopped) == 1 || ctx.Err() != nil {
+			return
+		}
+		// the display returned without a swap, it was closed
+		select {
+		case <-swapped:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Proxy) Framebuffer() *Framebuffer {
+	return p.fb
+}
+
+func (p *Proxy) GetLine(line Line) (string, error) {
+	return p.fb.Line(line)
+}
+
+func (p *Proxy) Snapshot() ([]string, error) {
+	return p.fb.Lines(), nil
+}
+
+func (p *Proxy) ObserveContent(fn func(c Content)) (cancel func()) {
+	return p.fb.Observe(fn)
+}
+
+func (p *Proxy) ObserveButtons(fn func(ev ButtonEvent)) (cancel func()) {
+	p.om.Lock()
+	defer p.om.Unlock()
+
+	id := p.nextID
+	p.nextID++
+	p.observers[id] = fn
+	return func() {
+		p.om.Lock()
+		defer p.om.Unlock()
+
+		delete(p.observers, id)
+	}
+}
+
+func (p *Proxy) DefaultKeymap() Keymap {
+	return DefaultKeymap(p.Current())
+}
+
+// observeButtons of lcd and pass them to the observers of the proxy.
+func (p *Proxy) observeButtons(lcd LCD) (cancel func()) {
+	cancel, _ = ObserveButtons(lcd, func(ev ButtonEvent) {
+		p.om.Lock()
+		defer p.om.Unlock()
+
+		for _, fn := range p.observers {
+			fn(ev)
+		}
+	})
+	return cancel
+}
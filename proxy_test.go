@@ -0,0 +1,43 @@
+package display
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProxySwapFitsTheGeometry(t *testing.T) {
+	p := NewProxy(newFakeLCD(2, 16))
+	if err := p.Write(LineOne, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Framebuffer().Lines()[0]; got != fit("hello", 16) {
+		t.Fatalf("got %q, want the line fit to 16 characters", got)
+	}
+
+	wide := newFakeLCD(4, 20)
+	if err := p.Swap(wide); err != nil {
+		t.Fatal(err)
+	}
+	empty := fit("", 20)
+	want := []string{fit("hello", 20), empty, empty, empty}
+	if got := p.Framebuffer().Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := wide.Framebuffer().Lines()[0]; strings.TrimSpace(got) != "hello" {
+		t.Errorf("content wasn't replayed, got %q", got)
+	}
+	if err := p.Write(Line(3), "four"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := p.Framebuffer().Line(3); got != fit("four", 20) {
+		t.Errorf("got %q, want the fourth line fit to 20 characters", got)
+	}
+
+	if err := p.Swap(newFakeLCD(2, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(p.Framebuffer().Lines()); got != 2 {
+		t.Errorf("got %d lines after swapping back, want 2", got)
+	}
+}
@@ -2,7 +2,8 @@ package display
 
 import (
 	"bytes"
-	"github.com/chmorgan/go-serial2/serial"
+	"context"
+	"github.com/artvel/display/qnapproto"
 	"io"
 	"log"
 	"sync"
@@ -10,23 +11,45 @@ import (
 )
 
 type (
-	btnAction struct {
-		btn      int
-		released bool
-	}
 	qnap struct {
-		tty           string
-		con           io.ReadWriteCloser
-		open          bool
-		keepListening bool
+		tty     string
+		con     *conn
+		connect connector
+		open    openFlag
+		// frozen displays ignore writes, see ShowShutdown
+		frozen bool
 
-		// to keep track of the 10ms
-		// we have to wait for to be flushed
-		lastFlush time.Time
+		m sync.Mutex
 
-		btnActionC chan btnAction
+		// to keep track of the delay
+		// we have to wait for to be flushed
+		pacer        pacer
+		clock        Clock
+		timeout      time.Duration
+		readTimeout  time.Duration
+		closeTimeout time.Duration
+		// the reader, pending reads and the writer, Close waits for
+		// them. Every Open starts a new group, the former might still
+		// wait for a stuck port.
+		bg *sync.WaitGroup
+		// closed to stop the reader
+		stop chan struct{}
 
-		waitForFlush time.Duration
+		btnC      chan ButtonEvent
+		drop      DropPolicy
+		counters  counters
+		tracer    *tracer
+		listeners *listeners
+		repeat    *repeater
+		fb        *Framebuffer
+		state     *lifecycle
+		onWrite   func(line Line, text string)
+		offline   *offlineQueue
+		// writes while disabled
+		retained retained
+		errC     chan error
+		// the button currently held down
+		lastBtn int
 
 		// keep the fields packed inside the struct
 		// to simplify the implementation of other
@@ -38,15 +61,30 @@ type (
 		downPressed []byte
 		bothPressed []byte
 
-		cmdBtn     []byte
 		cmdEnable  []byte
 		cmdDisable []byte
-		cmdWrite   []byte
-		cmdInit    []byte
-		cmdRdy     []byte
+		width      int
+		lines      int
+		// handshakes tried by init, the first matching one is kept
+		handshakes []qnapproto.Handshake
+		handshake  string
 	}
 )
 
+// The raw button ids the QNAP driver passes to Listen. QnapRawBoth is
+// reported if up and down are held together.
+const (
+	QnapRawUp   = int(qnapproto.ButtonUp)
+	QnapRawDown = int(qnapproto.ButtonDown)
+	QnapRawBoth = int(qnapproto.ButtonBoth)
+)
+
+var qnapButtons = map[int]Button{
+	QnapRawUp:   ButtonUp,
+	QnapRawDown: ButtonDown,
+	QnapRawBoth: ButtonBoth,
+}
+
 /**
 Supports the display of the following devices:
 	QNAP TVS-x72XT
@@ -55,27 +93,72 @@ Supports the display of the following devices:
 The constructor is responsible for init and probe.
 To simplify and unify the use of future displays.
 */
-func NewQnapLCD(tty string) (LCD, error) {
+func NewQnapLCD(tty string, opts ...Option) (LCD, error) {
 	if tty == "" {
 		tty = DefaultTTy
 	}
-	cmdBtn := []byte{83, 5, 0}
+	o := newOptions(opts)
+	return newQnap(tty, o.serialConnector(qnapSerial(tty)), o)
+}
+
+func qnapSerial(tty string) SerialConfig {
+	return SerialConfig{
+		PortName:        tty,
+		BaudRate:        1200,
+		DataBits:        8,
+		StopBits:        1,
+		MinimumReadSize: 4,
+		Rs485RxDuringTx: true,
+	}
+}
+
+// NewQnapLCDFromConn uses an already opened connection,
+// like an in-memory pipe, a pty bridge or a TCP link, instead of
+// a serial port. Once closed, the display can't be reopened.
+func NewQnapLCDFromConn(rwc io.ReadWriteCloser, opts ...Option) (LCD, error) {
+	return newQnap("", connOnce(rwc), newOptions(opts))
+}
+
+func newQnap(tty string, connect connector, o *options) (LCD, error) {
 	q := &qnap{
-		tty: tty,
+		tty:          tty,
+		connect:      connect,
+		clock:        o.clock,
+		pacer:        pacer{clock: o.clock, delay: durationOr(o.writeDelay, DefaultQnapDelayBetweenWrites)},
+		timeout:      durationOr(o.writeTimeout, DefaultWriteTimeout),
+		readTimeout:  durationOr(o.readTimeout, DefaultQnapReadTimeout),
+		closeTimeout: durationOr(o.closeTimeout, DefaultCloseTimeout),
 
-		waitForFlush: 135 * time.Millisecond,
+		released:    qnapproto.Frame{Report: qnapproto.ReportButton, Value: qnapproto.ButtonReleased}.Encode(),
+		upPressed:   qnapproto.Frame{Report: qnapproto.ReportButton, Value: qnapproto.ButtonUp}.Encode(),
+		downPressed: qnapproto.Frame{Report: qnapproto.ReportButton, Value: qnapproto.ButtonDown}.Encode(),
+		bothPressed: qnapproto.Frame{Report: qnapproto.ReportButton, Value: qnapproto.ButtonBoth}.Encode(),
 
-		released:    append(cmdBtn, 0),
-		upPressed:   append(cmdBtn, 1),
-		downPressed: append(cmdBtn, 2),
-		bothPressed: append(cmdBtn, 3),
+		btnC:      make(chan ButtonEvent, o.queueLen()),
+		drop:      o.dropPolicy,
+		tracer:    newTracer(o.tracer, "qnap"),
+		listeners: newListeners(qnapButtons),
+		repeat:    o.newRepeater(),
+		lines:     intOr(o.lines, qnapproto.Lines),
+		state:     newLifecycle(o.stateHook),
+		onWrite:   o.writeHook,
+		offline:   newOfflineQueue(o.offlineDepth),
+		retained:  retained{policy: o.disabledPolicy},
+		errC:      make(chan error, errBufferSize),
 
-		cmdBtn:     cmdBtn,
-		cmdEnable:  []byte{77, 94, 1, 10},
-		cmdDisable: []byte{77, 94, 0, 10},
-		cmdWrite:   []byte{77, 94, 1},
-		cmdInit:    []byte{77, 0},
-		cmdRdy:     []byte{83, 1, 0, 125},
+		cmdEnable:  qnapproto.EncodeEnable(true),
+		cmdDisable: qnapproto.EncodeEnable(false),
+		width:      intOr(o.width, qnapproto.Width),
+		handshakes: qnapproto.Handshakes,
+	}
+	q.fb = NewFramebuffer(q.lines)
+	if len(o.qnapHandshakes) > 0 {
+		q.handshakes = o.qnapHandshakes
+	} else if o.anyReady {
+		q.handshakes = append(append([]qnapproto.Handshake(nil), q.handshakes...), qnapproto.AnyReady)
+	}
+	if o.buttonLog != nil {
+		q.listeners.observe(o.buttonLog.Record)
 	}
 	err := q.init()
 	if err != nil {
@@ -84,64 +167,158 @@ func NewQnapLCD(tty string) (LCD, error) {
 	return q, err
 }
 
+// Port the display is connected to, empty for a connection.
+func (q *qnap) Port() string {
+	return q.tty
+}
+
+func (q *qnap) timeSource() Clock {
+	return q.clock
+}
+
 func (q *qnap) Open() error {
-	if q.open {
+	if err := q.openLocked(); err != nil {
+		return err
+	}
+	return q.offline.replay(q)
+}
+
+func (q *qnap) openLocked() error {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if q.open.get() {
 		return nil
 	}
 	return q.init()
 }
 
-func (q *qnap) init() error {
-	var err error
-	q.con, err = serial.Open(serial.OpenOptions{
-		PortName:        q.tty,
-		BaudRate:        1200,
-		DataBits:        8,
-		StopBits:        1,
-		MinimumReadSize: 4,
-		Rs485RxDuringTx: true,
-	})
-	if err != nil {
-		return err
-	}
+func (q *qnap) init() (err error) {
+	end := q.tracer.start("display.open", "display.tty", q.tty)
+	defer func() { end(err) }()
+	q.state.set(StateOpening)
 	defer func() {
-		if r := recover(); r != nil {
-			log.Println("display panic when trying to init")
+		if err != nil {
+			q.state.set(StateClosed)
 		}
 	}()
-	_, err = q.con.Write(q.cmdInit)
-	if err != nil {
+
+	defer recovered("open", &err)
+	q.bg = &sync.WaitGroup{}
+	var reply []byte
+	for i, h := range q.handshakes {
+		if i == 0 || !bytes.Equal(h.Init, q.handshakes[i-1].Init) {
+			if reply, err = q.exchange(h.Init, i == 0); err != nil {
+				return err
+			}
+		}
+		if !h.Match(reply) {
+			continue
+		}
+		if h.Name != q.handshake && i > 0 {
+			log.Printf("qnap: using the %s handshake", h.Name)
+		}
+		q.handshake = h.Name
+		q.open.set(true)
+		q.stop = make(chan struct{})
+		q.bg.Add(1)
+		go q.read(q.con, q.stop, q.bg)
+		q.counters.opened()
+		q.state.set(StateReady)
+		return nil
+	}
+	q.open.set(false)
+	if q.con != nil {
 		_ = q.con.Close()
-		return err
 	}
-	i := 0
-	res := make([]byte, 4)
-	i, err = q.readWithTimeout(res)
+	return ErrDisplayNotWorking
+}
+
+// exchange sends the init command of a handshake and returns the reply.
+// Unanswered commands close the connection, it is reopened for the
+// next one.
+func (q *qnap) exchange(init []byte, first bool) ([]byte, error) {
+	if first || q.con == nil {
+		con, err := dial(q.connect, q.bg)
+		if err != nil {
+			if !first {
+				return nil, ErrDisplayNotWorking
+			}
+			return nil, err
+		}
+		q.con = con
+	}
+	_, err := writeTimeout(q.clock, q.con, init, q.timeout)
+	q.counters.wrote(err)
 	if err != nil {
 		_ = q.con.Close()
-		return ErrDisplayNotWorking
+		return nil, err
 	}
-	if bytes.Equal(res[0:i], q.cmdRdy) {
-		q.open = true
-		return nil
-	} else {
-		q.open = false
+	res := make([]byte, qnapproto.FrameSize)
+	n, err := q.readWithTimeout(res)
+	if err == nil || err == ErrDisplayNotWorking {
+		q.counters.replied(err == nil)
+	}
+	if err != nil {
 		_ = q.con.Close()
-		return ErrDisplayNotWorking
+		q.con = nil
+		if _, ok := err.(*PanicError); ok {
+			return nil, err
+		}
+		return nil, nil
 	}
+	return res[:n], nil
 }
 
 func (q *qnap) Write(line Line, txt string) error {
-	if !q.open {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	return q.writeLine(line, txt)
+}
+
+// writeLines of a transaction with a single lock acquisition.
+func (q *qnap) writeLines(lines []lineText) error {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	return commitLines(q.fb, q.writeLine, lines)
+}
+
+// writeLine must be called with the lock held.
+func (q *qnap) writeLine(line Line, txt string) (err error) {
+	if q.frozen {
+		return nil
+	}
+	end := q.tracer.start("display.write", "display.line", int(line))
+	defer func() { end(err) }()
+
+	if line < 0 || int(line) >= q.lines {
+		return ErrUnsupportedLine
+	}
+	if !q.open.get() {
+		if q.offline.write(line, txt) {
+			return nil
+		}
 		return ErrClosed
 	}
-	txt = prepareTxt(txt)
+	if !q.fb.Enabled() {
+		// the write command would turn the display on
+		if err = q.retained.write(line, txt); err == nil {
+			q.fb.Set(line, fit(txt, q.width))
+		}
+		return err
+	}
+	txt = fit(txt, q.width)
 
-	cnt := append(append(q.cmdWrite, 77, 12, byte(line), 16), []byte(txt)...)
+	cnt := qnapproto.EncodeWrite(byte(line), []byte(txt))
 
-	q.waitForFlushBetweenWrites()
+	q.pacer.wait()
 
-	n, err := q.con.Write(cnt)
+	rt := q.tracer.start("display.roundtrip")
+	n, err := q.send(cnt)
+	rt(err)
+	q.state.result(err)
 	if err != nil {
 		return err
 	}
@@ -149,155 +326,299 @@ func (q *qnap) Write(line Line, txt string) error {
 		return ErrMsgSizeMismatch
 	}
 	q.waitForDisplaying()
+	// the write command turns the display on as well
+	q.fb.Set(line, txt)
+	q.fb.SetEnabled(true)
+	if q.onWrite != nil {
+		q.onWrite(line, txt)
+	}
 	return nil
 }
 
-func (q *qnap) Enable(yes bool) error {
-	if !q.open {
+func (q *qnap) Enable(yes bool) (err error) {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if q.frozen {
+		return nil
+	}
+	end := q.tracer.start("display.enable", "display.enabled", yes)
+	defer func() { end(err) }()
+
+	if !q.open.get() {
+		if q.offline.setEnabled(yes) {
+			return nil
+		}
 		return ErrClosed
 	}
+	cmd := q.cmdDisable
 	if yes {
-		_, err := q.con.Write(q.cmdEnable)
-		return err
-	} else {
-		_, err := q.con.Write(q.cmdDisable)
+		cmd = q.cmdEnable
+	}
+	// the panel can't be asked, but it ignores commands arriving
+	// while it still processes the previous one
+	q.pacer.wait()
+	n, err := q.send(cmd)
+	if err == nil && n != len(cmd) {
+		err = ErrMsgSizeMismatch
+	}
+	q.state.result(err)
+	if err != nil {
 		return err
 	}
+	q.waitForDisplaying()
+	q.fb.SetEnabled(yes)
+	if yes {
+		return q.retained.replay(q.writeLine)
+	}
+	return nil
+}
+
+// send b to the open display, a timeout closes the connection.
+func (q *qnap) send(b []byte) (int, error) {
+	n, err := writeTimeout(q.clock, q.con, b, q.timeout)
+	q.counters.wrote(err)
+	if err == ErrWriteTimeout {
+		// unblock the pending write, Open reconnects
+		_ = q.forceClose()
+		reportErr(q.errC, err)
+	}
+	return n, err
 }
 
 func (q *qnap) waitForDisplaying() {
-	time.Sleep(q.waitForFlush)
+	q.clock.Sleep(q.pacer.delay)
 }
 
-func (q *qnap) waitForFlushBetweenWrites() {
-	timeDiff := q.lastFlush.Add(q.waitForFlush).Sub(time.Now())
-	if timeDiff > 0 {
-		time.Sleep(timeDiff)
+// Flush blocks until the last write was processed by the display.
+func (q *qnap) Flush() error {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if !q.open.get() {
+		return ErrClosed
 	}
-	q.lastFlush = time.Now()
+	q.pacer.settle()
+	return nil
 }
 
 func (q *qnap) Listen(l func(btn int, released bool) bool) {
-	if !q.open {
+	q.ListenEvents(func(ev ButtonEvent) bool {
+		return l(ev.Raw, ev.Released)
+	})
+}
+
+// Width of a line in characters.
+func (q *qnap) Width() int {
+	return q.width
+}
+
+func (q *qnap) buttonMap() map[int]Button {
+	return qnapButtons
+}
+
+// StopListening ends the pending Listen calls, the display stays open.
+func (q *qnap) StopListening() {
+	q.listeners.stopAll()
+}
+
+func (q *qnap) ListenEvents(l func(ev ButtonEvent) bool) {
+	q.ListenEventsContext(context.Background(), l)
+}
+
+func (q *qnap) ListenEventsContext(ctx context.Context, l func(ev ButtonEvent) bool) {
+	if !q.open.get() {
 		return
 	}
+	q.listeners.listen(ctx, q.clock.Now(), q.open.get, q.btnC, l)
+}
+
+func (q *qnap) freeze() {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	q.frozen = true
+}
+
+func (q *qnap) closeNotify() <-chan struct{} {
+	return q.state.closeNotify()
+}
+
+func (q *qnap) Framebuffer() *Framebuffer {
+	return q.fb
+}
+
+func (q *qnap) GetLine(line Line) (string, error) {
+	return q.fb.Line(line)
+}
+
+func (q *qnap) Snapshot() ([]string, error) {
+	return q.fb.Lines(), nil
+}
+
+func (q *qnap) ObserveContent(fn func(c Content)) (cancel func()) {
+	return q.fb.Observe(fn)
+}
 
-	q.keepListening = true
-	q.btnActionC = make(chan btnAction, 100)
-	go q.btnActionRoutine(l)
+func (q *qnap) ObserveButtons(fn func(ev ButtonEvent)) (cancel func()) {
+	return q.listeners.observe(fn)
+}
+
+func (q *qnap) DefaultKeymap() Keymap {
+	return QnapKeymap
+}
+
+func (q *qnap) ConnState() ConnState {
+	return q.state.get()
+}
+
+func (q *qnap) Stats() Stats {
+	return q.counters.stats()
+}
+
+// Errors delivers the errors of the background reader.
+func (q *qnap) Errors() <-chan error {
+	return q.errC
+}
+
+// InjectButton passes a synthesized button event to Listen
+// as if it was sent by the display.
+func (q *qnap) InjectButton(btn int, released bool) error {
+	if !q.open.get() {
+		return ErrClosed
+	}
+	q.queue(btn, released)
+	return nil
+}
 
+// read reads asynchronously from the serial port
+// and transmits button events on the btn channel.
+func (q *qnap) read(con io.Reader, stop <-chan struct{}, bg *sync.WaitGroup) {
+	defer bg.Done()
+	var err error
 	defer func() {
-		if r := recover(); r != nil {
-			log.Println("display panic while listening")
+		if err != nil {
+			q.state.result(err)
+			reportErr(q.errC, err)
 		}
-		close(q.btnActionC)
 	}()
-	var lastBtn = 0
-	for q.open && q.keepListening {
-		res := make([]byte, 4)
-		n, err := q.con.Read(res)
-		if err != nil || !q.open || !q.keepListening {
+	defer recovered("read", &err)
+	parser := qnapproto.NewParser()
+	buf := make([]byte, 16)
+	for {
+		var n int
+		n, err = con.Read(buf)
+		select {
+		case <-stop:
+			err = nil
 			return
+		default:
 		}
-		if n != len(res) {
-			continue
-		}
-		res = q.ensureOrder(res)
-		if bytes.Equal(res, q.released) {
-			q.btnActionC <- btnAction{btn: lastBtn, released: true}
-			lastBtn = 0
-		} else if bytes.Equal(res, q.upPressed) {
-			if lastBtn == 3 {
-				continue
-			}
-			lastBtn = 1
-			q.btnActionC <- btnAction{btn: lastBtn, released: false}
-		} else if bytes.Equal(res, q.downPressed) {
-			if lastBtn == 3 {
-				continue
-			}
-			lastBtn = 2
-			q.btnActionC <- btnAction{btn: lastBtn, released: false}
-		} else if bytes.Equal(res, q.bothPressed) {
-			lastBtn = 3
-			q.btnActionC <- btnAction{btn: lastBtn, released: false}
-		}
-	}
-}
-
-func (q *qnap) btnActionRoutine(l func(btn int, released bool) bool) {
-	for q.open && q.keepListening {
-		btnAction, ok := <-q.btnActionC
-		if !ok {
+		if err != nil {
 			return
 		}
-		if q.keepListening = l(btnAction.btn, btnAction.released); !q.keepListening {
-			return
+		for _, res := range parser.Feed(buf[:n]) {
+			q.pass(res)
 		}
 	}
 }
 
-func (q *qnap) ensureOrder(res []byte) []byte {
-	if bytes.HasPrefix(res, q.cmdBtn) {
-		return res
+func (q *qnap) queue(btn int, released bool) {
+	if released {
+		q.repeat.release()
 	}
-	// happens only if read and write cross to much
-	ordered := make([]byte, 4)
-	for i, b := range q.cmdBtn {
-		for c, r := range res {
-			if b == r {
-				ordered[i] = b
-				res = remove(res, c)
-				break
-			}
-		}
+	send := func(ev ButtonEvent) {
+		q.counters.dropped(q.drop.sendEvent(q.btnC, ev))
 	}
-	if len(res) > 0 {
-		ordered[3] = res[0]
+	q.listeners.emit(btn, released, q.clock.Now(), send)
+	if !released {
+		q.repeat.press(btn, func() {
+			q.listeners.repeat(btn, q.clock.Now(), send)
+		})
 	}
-	return ordered
 }
 
-func remove(s []byte, i int) []byte {
-	s[len(s)-1], s[i] = s[i], s[len(s)-1]
-	return s[:len(s)-1]
+// pass a frame as button event to the btn channel.
+func (q *qnap) pass(res []byte) {
+	if bytes.Equal(res, q.released) {
+		q.queue(q.lastBtn, true)
+		q.lastBtn = 0
+	} else if bytes.Equal(res, q.upPressed) {
+		if q.lastBtn == QnapRawBoth {
+			return
+		}
+		q.lastBtn = QnapRawUp
+		q.queue(q.lastBtn, false)
+	} else if bytes.Equal(res, q.downPressed) {
+		if q.lastBtn == QnapRawBoth {
+			return
+		}
+		q.lastBtn = QnapRawDown
+		q.queue(q.lastBtn, false)
+	} else if bytes.Equal(res, q.bothPressed) {
+		q.lastBtn = QnapRawBoth
+		q.queue(q.lastBtn, false)
+	}
 }
 
-func (q *qnap) readWithTimeout(res []byte) (i int, err error) {
-	respReceived := false
-	waiter := sync.WaitGroup{}
-	waiter.Add(2)
+// readWithTimeout closes the connection if the display doesn't reply
+// within the read timeout and waits up to the close timeout for the read to return.
+func (q *qnap) readWithTimeout(res []byte) (int, error) {
+	type result struct {
+		n   int
+		err error
+	}
+	c := make(chan result, 1)
+	con, bg := q.con, q.bg
+	bg.Add(1)
 	go func() {
-		i, err = q.con.Read(res)
-		if err == nil {
-			respReceived = true
-			waiter.Done()
-			waiter.Done()
-		} else {
-			waiter.Done()
-		}
+		defer bg.Done()
+		var r result
+		defer func() { c <- r }()
+		defer recovered("read", &r.err)
+		r.n, r.err = con.Read(res)
 	}()
-	time.AfterFunc(300*time.Millisecond, func() {
-		if respReceived {
-			return
-		}
+	select {
+	case r := <-c:
+		return r.n, r.err
+	case <-q.clock.After(q.readTimeout):
 		_ = q.forceClose()
-		err = ErrDisplayNotWorking
-		waiter.Done()
-	})
-	waiter.Wait()
-	return
+		select {
+		case <-c:
+		case <-q.clock.After(q.closeTimeout):
+		}
+		return 0, ErrDisplayNotWorking
+	}
 }
 
+// Close the connection once the last write was processed, for example
+// a shutdown message. It waits up to the close timeout for the
+// listeners and the reader to return.
 func (q *qnap) Close() error {
-	if !q.open {
+	q.m.Lock()
+	if !q.open.get() {
+		q.m.Unlock()
 		return nil
 	}
-	return q.forceClose()
+	q.state.set(StateClosing)
+	q.pacer.settle()
+	err := q.forceClose()
+	bg := q.bg
+	q.m.Unlock()
+
+	await(q.clock, q.closeTimeout, waitDone(bg), q.listeners.stopped())
+	return err
 }
 
 func (q *qnap) forceClose() error {
-	q.open = false
+	q.open.set(false)
+	q.repeat.release()
+	if q.stop != nil {
+		close(q.stop)
+		q.stop = nil
+	}
+	q.state.set(StateClosed)
+	q.listeners.wakeAll()
 	if q.con == nil {
 		return nil
 	}
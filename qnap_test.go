@@ -0,0 +1,68 @@
+package display
+
+import (
+	"github.com/artvel/display/emulator"
+	"testing"
+	"time"
+)
+
+func newTestQnap(t *testing.T, opts ...Option) (*emulator.Qnap, LCD) {
+	t.Helper()
+	emu, con := emulator.NewQnap()
+	lcd, err := NewQnapLCDFromConn(con, append([]Option{WithClock(newFakeClock())}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = lcd.Close() })
+	return emu, lcd
+}
+
+func TestQnapWriteValidatesTheLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines int
+		line  Line
+		err   error
+	}{
+		{"first line", 0, LineOne, nil},
+		{"second line", 0, LineTwo, nil},
+		{"negative line", 0, -1, ErrUnsupportedLine},
+		{"third line", 0, 2, ErrUnsupportedLine},
+		{"two digit line", 0, 10, ErrUnsupportedLine},
+		{"third line of a larger model", 4, 2, nil},
+		{"beyond a larger model", 4, 4, ErrUnsupportedLine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, lcd := newTestQnap(t, WithQuirks(Quirks{Lines: tt.lines}))
+			if err := lcd.Write(tt.line, "hello"); err != tt.err {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestQnapWriteShowsTheText(t *testing.T) {
+	emu, lcd := newTestQnap(t)
+	if err := lcd.Write(LineTwo, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	// the emulator shows the text once it read all of it
+	deadline := time.Now().Add(time.Second)
+	for emu.Lines()[1] != "hello" {
+		if time.Now().After(deadline) {
+			t.Fatalf("display shows %q", emu.Lines())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQnapWriteValidatesTheLineWhileClosed(t *testing.T) {
+	_, lcd := newTestQnap(t, WithOfflineBuffer(2))
+	if err := lcd.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lcd.Write(2, "hello"); err != ErrUnsupportedLine {
+		t.Errorf("got %v, want ErrUnsupportedLine", err)
+	}
+}
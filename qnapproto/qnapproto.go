@@ -0,0 +1,152 @@
+/*
+Package qnapproto implements the serial wire format of the
+QNAP LCD display without any of the serial plumbing.
+It has no dependencies and compiles with TinyGo.
+
+Commands sent to the display start with 'M', reports sent
+by the display are always four bytes starting with 'S':
+
+	'S' REPORT 0 VALUE
+*/
+package qnapproto
+
+import (
+	"errors"
+	"github.com/artvel/display/internal/frame"
+)
+
+type (
+	// Frame is a decoded report of the display.
+	Frame struct {
+		Report byte
+		Value  byte
+	}
+	// Handshake is an init command and the ready report answering it.
+	Handshake struct {
+		Name string
+		Init []byte
+		// Ready is the expected report, a zero Value accepts every
+		// ready report.
+		Ready Frame
+	}
+	// Parser splits a byte stream into report frames.
+	Parser struct {
+		p frame.Parser
+	}
+)
+
+const (
+	// ByteCommand starts every command.
+	ByteCommand byte = 'M'
+	// ByteReport starts every report.
+	ByteReport byte = 'S'
+	// FrameSize of a report.
+	FrameSize = 4
+	// Width of a line in characters.
+	Width = 16
+	// Lines of the display.
+	Lines = 2
+)
+
+// Reports
+const (
+	ReportReady  byte = 1
+	ReportButton byte = 5
+)
+
+// Values
+const (
+	ReadyValue byte = 125
+
+	ButtonReleased byte = 0
+	ButtonUp       byte = 1
+	ButtonDown     byte = 2
+	ButtonBoth     byte = 3
+)
+
+var ErrFrame = errors.New("qnap frame invalid")
+
+// Handshakes known from the different models, tried in order. Add the
+// exchange of a model here if it answers differently.
+var Handshakes = []Handshake{
+	{Name: "default", Init: EncodeInit(), Ready: Frame{Report: ReportReady, Value: ReadyValue}},
+}
+
+// AnyReady accepts every ready report, some TS and TVS firmware
+// revisions report another value. It isn't one of the Handshakes as
+// any stray report would pass for a working display, the driver tries
+// it last for models with the AnyReady quirk.
+var AnyReady = Handshake{Name: "any-ready", Init: EncodeInit(), Ready: Frame{Report: ReportReady}}
+
+// Encode the report.
+func (f Frame) Encode() []byte {
+	return []byte{ByteReport, f.Report, 0, f.Value}
+}
+
+// Decode a report.
+func Decode(b []byte) (Frame, error) {
+	if len(b) != FrameSize || b[0] != ByteReport || b[2] != 0 {
+		return Frame{}, ErrFrame
+	}
+	if b[1] != ReportReady && b[1] != ReportButton {
+		return Frame{}, ErrFrame
+	}
+	return Frame{Report: b[1], Value: b[3]}, nil
+}
+
+// Match reports whether b is the ready report of the handshake.
+func (h Handshake) Match(b []byte) bool {
+	f, err := Decode(b)
+	if err != nil || f.Report != h.Ready.Report {
+		return false
+	}
+	return h.Ready.Value == 0 || f.Value == h.Ready.Value
+}
+
+// EncodeInit asks the display to report ready.
+func EncodeInit() []byte {
+	return []byte{ByteCommand, 0}
+}
+
+// EncodeEnable turns the display on or off.
+func EncodeEnable(on bool) []byte {
+	if on {
+		return []byte{ByteCommand, 94, 1, 10}
+	}
+	return []byte{ByteCommand, 94, 0, 10}
+}
+
+// EncodeWrite writes text on line. The display is enabled by the
+// same command. The text has to be padded to Width and line checked
+// against Lines by the caller.
+func EncodeWrite(line byte, text []byte) []byte {
+	b := make([]byte, 0, 7+len(text))
+	b = append(b, ByteCommand, 94, 1, ByteCommand, 12, line, byte(len(text)))
+	return append(b, text...)
+}
+
+// NewParser for a stream of reports read from the display.
+func NewParser() *Parser {
+	return &Parser{p: frame.Parser{
+		Start: func(b byte) bool {
+			return b == ByteReport
+		},
+		Size: func(buf []byte) int {
+			return FrameSize
+		},
+		Valid: func(b []byte) bool {
+			_, err := Decode(b)
+			return err == nil
+		},
+	}}
+}
+
+// Feed the bytes of a read and get all complete frames encoded.
+func (p *Parser) Feed(data []byte) [][]byte {
+	return p.p.Feed(data)
+}
+
+// Reset drops a partial frame.
+func (p *Parser) Reset() {
+	p.p.Reset()
+}
@@ -0,0 +1,28 @@
+//go:build go1.18
+// +build go1.18
+
+package qnapproto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzParse(f *testing.F) {
+	f.Add(Frame{Report: ReportReady, Value: ReadyValue}.Encode())
+	f.Add([]byte{ByteReport, ByteReport, ReportButton, 0, ButtonDown})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p := NewParser()
+		frames := p.Feed(data[:len(data)/2])
+		frames = append(frames, p.Feed(data[len(data)/2:])...)
+		for _, b := range frames {
+			f, err := Decode(b)
+			if err != nil {
+				t.Fatalf("parser returned invalid frame %x: %v", b, err)
+			}
+			if !bytes.Equal(f.Encode(), b) {
+				t.Fatalf("%x re-encoded to %x", b, f.Encode())
+			}
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package qnapproto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+		want  Frame
+		err   error
+	}{
+		{"ready", []byte{ByteReport, ReportReady, 0, ReadyValue}, Frame{Report: ReportReady, Value: ReadyValue}, nil},
+		{"button", []byte{ByteReport, ReportButton, 0, ButtonUp}, Frame{Report: ReportButton, Value: ButtonUp}, nil},
+		{"unknown report", []byte{ByteReport, 9, 0, 1}, Frame{}, ErrFrame},
+		{"padding byte set", []byte{ByteReport, ReportButton, 1, ButtonUp}, Frame{}, ErrFrame},
+		{"truncated", []byte{ByteReport, ReportButton, 0}, Frame{}, ErrFrame},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Decode(tt.frame)
+			if err != tt.err || f != tt.want {
+				t.Fatalf("got %v, %v, want %v, %v", f, err, tt.want, tt.err)
+			}
+			if err == nil && !bytes.Equal(f.Encode(), tt.frame) {
+				t.Errorf("re-encoded %x, want %x", f.Encode(), tt.frame)
+			}
+		})
+	}
+}
+
+func TestParser(t *testing.T) {
+	up := Frame{Report: ReportButton, Value: ButtonUp}.Encode()
+	released := Frame{Report: ReportButton, Value: ButtonReleased}.Encode()
+	cat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	tests := []struct {
+		name  string
+		reads [][]byte
+		want  [][]byte
+	}{
+		{"valid frames", [][]byte{cat(up, released)}, [][]byte{up, released}},
+		{"invalid frame", [][]byte{{ByteReport, 9, 0, 1}}, nil},
+		{"resync after garbage", [][]byte{cat([]byte{1, ByteReport, 2}, up)}, [][]byte{up}},
+		{"split reads", [][]byte{up[:1], cat(up[1:], released[:3]), released[3:]}, [][]byte{up, released}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser()
+			var got [][]byte
+			for _, r := range tt.reads {
+				got = append(got, p.Feed(r)...)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %x, want %x", got, tt.want)
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], tt.want[i]) {
+					t.Errorf("frame %d is %x, want %x", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHandshakeMatch(t *testing.T) {
+	other := Frame{Report: ReportReady, Value: 7}.Encode()
+	if Handshakes[0].Match(other) {
+		t.Error("default handshake accepted another ready value")
+	}
+	if !AnyReady.Match(other) {
+		t.Error("any-ready handshake rejected another ready value")
+	}
+	for _, h := range Handshakes {
+		if h.Ready.Value == 0 {
+			t.Errorf("the %s handshake accepts every ready report by default", h.Name)
+		}
+	}
+}
+
+func TestEncodeWrite(t *testing.T) {
+	want := []byte{ByteCommand, 94, 1, ByteCommand, 12, 10, 2, 'h', 'i'}
+	if got := EncodeWrite(10, []byte("hi")); !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package display
+
+// DropPolicy decides what happens to button events if the queue
+// of a driver is full because nobody consumes it.
+type DropPolicy int
+
+const (
+	// Block the reader until there is room again. Without a
+	// listener this stops the reader and with it acknowledged writes.
+	Block DropPolicy = iota
+	// DropOldest makes room by dropping the oldest queued entry.
+	DropOldest
+	// DropNewest drops the entry which doesn't fit.
+	DropNewest
+)
+
+// DefaultQueueSize of the button queues.
+const DefaultQueueSize = 100
+
+// WithQueueSize sets the size of the button queues.
+func WithQueueSize(n int) Option {
+	return func(o *options) {
+		o.queueSize = n
+	}
+}
+
+// WithDropPolicy sets the behaviour of full queues, default is Block.
+func WithDropPolicy(p DropPolicy) Option {
+	return func(o *options) {
+		o.dropPolicy = p
+	}
+}
+
+func (o *options) queueLen() int {
+	if o.queueSize > 0 {
+		return o.queueSize
+	}
+	return DefaultQueueSize
+}
+
+// sendEvent queues ev and reports false if an entry was dropped.
+func (p DropPolicy) sendEvent(c chan ButtonEvent, ev ButtonEvent) bool {
+	if p == Block {
+		c <- ev
+		return true
+	}
+	for {
+		select {
+		case c <- ev:
+			return true
+		default:
+		}
+		if p == DropNewest {
+			return false
+		}
+		select {
+		case <-c:
+			return false
+		default:
+		}
+	}
+}
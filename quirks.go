@@ -0,0 +1,60 @@
+package display
+
+import "time"
+
+// Quirks of a hardware revision deviating from the defaults of its
+// driver. The known models carry theirs, so supporting a new revision
+// is an entry in the model table instead of a fork of the driver.
+// Zero values keep the default.
+type Quirks struct {
+	// WriteDelay between two frames.
+	WriteDelay time.Duration
+	// Retries of an unacknowledged ASUSTOR frame, 10 by default.
+	Retries int
+	// Width of a line in characters, 16 by default.
+	Width int
+	// Lines of the display, 2 by default. Writes to other lines fail
+	// with ErrUnsupportedLine.
+	Lines int
+	// AckTimeout the ASUSTOR driver waits for the acknowledgement of
+	// a frame, DefaultAsustorReadTimeout by default like WithReadTimeout.
+	AckTimeout time.Duration
+	// NoAck is set for ASUSTOR firmware which doesn't acknowledge
+	// writes.
+	NoAck bool
+	// AnyReady is set for QNAP firmware which reports another ready
+	// value, the driver then accepts any ready report after the known
+	// handshakes.
+	AnyReady bool
+}
+
+const defaultRetries = 10
+
+// WithQuirks applies the quirks of a hardware revision. Find does it
+// for the known models before the given options, so they override
+// single settings like the write delay or WithAck.
+func WithQuirks(q Quirks) Option {
+	return func(o *options) {
+		if q.WriteDelay > 0 {
+			o.writeDelay = q.WriteDelay
+		}
+		if q.Retries > 0 {
+			o.retries = q.Retries
+		}
+		if q.Width > 0 {
+			o.width = q.Width
+		}
+		if q.Lines > 0 {
+			o.lines = q.Lines
+		}
+		if q.AckTimeout > 0 {
+			o.readTimeout = q.AckTimeout
+		}
+		if q.NoAck {
+			o.noAck = true
+		}
+		if q.AnyReady {
+			o.anyReady = true
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package display
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExplicitOptionsOverrideQuirks(t *testing.T) {
+	quirks := WithQuirks(Quirks{NoAck: true, WriteDelay: time.Second})
+	tests := []struct {
+		name  string
+		opts  []Option
+		noAck bool
+		delay time.Duration
+	}{
+		{"quirks", []Option{quirks}, true, time.Second},
+		{"ack after quirks", []Option{quirks, WithAck(true)}, false, time.Second},
+		{"delay after quirks", []Option{quirks, WithWriteDelay(time.Millisecond)}, true, time.Millisecond},
+		{"no ack without quirks", []Option{WithAck(false)}, true, 0},
+		{"zero quirks keep the options", []Option{WithAck(false), WithQuirks(Quirks{})}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions(tt.opts)
+			if o.noAck != tt.noAck || o.writeDelay != tt.delay {
+				t.Errorf("got noAck %v and delay %v, want %v and %v", o.noAck, o.writeDelay, tt.noAck, tt.delay)
+			}
+		})
+	}
+}
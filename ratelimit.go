@@ -0,0 +1,107 @@
+package display
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	rateLimit struct {
+		decorator
+		interval time.Duration
+		clock    Clock
+		m        sync.Mutex
+		lines    map[Line]*limitedLine
+		// err of the last delayed write, returned by Flush
+		err error
+	}
+
+	limitedLine struct {
+		last    time.Time
+		pending bool
+		text    string
+		timer   Timer
+	}
+)
+
+// RateLimit writes each line at most once per interval. Writes in
+// between are coalesced, only the latest text of a line is written once
+// its interval passed, so chatty applications like log tails or
+// counters can't flood slow panels. Delayed writes fail silently,
+// Flush writes the pending lines right away and returns their error.
+func RateLimit(interval time.Duration) Middleware {
+	return func(lcd LCD) LCD {
+		return &rateLimit{decorator: decorator{lcd}, interval: interval, clock: ClockOf(lcd), lines: map[Line]*limitedLine{}}
+	}
+}
+
+func (d *rateLimit) Write(line Line, text string) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	l := d.lines[line]
+	if l == nil {
+		l = &limitedLine{}
+		d.lines[line] = l
+	}
+	wait := d.interval - d.clock.Now().Sub(l.last)
+	if wait <= 0 && !l.pending {
+		l.last = d.clock.Now()
+		return d.LCD.Write(line, text)
+	}
+	l.text = text
+	if !l.pending {
+		l.pending = true
+		l.timer = d.clock.AfterFunc(wait, func() {
+			d.m.Lock()
+			defer d.m.Unlock()
+
+			d.writePending(line, l)
+		})
+	}
+	return nil
+}
+
+// writePending must be called with the lock held.
+func (d *rateLimit) writePending(line Line, l *limitedLine) error {
+	if !l.pending {
+		return nil
+	}
+	l.timer.Stop()
+	l.pending = false
+	l.last = d.clock.Now()
+	err := d.LCD.Write(line, l.text)
+	if err != nil {
+		d.err = err
+	}
+	return err
+}
+
+// Flush writes the pending lines and flushes the display.
+func (d *rateLimit) Flush() error {
+	d.m.Lock()
+	for line, l := range d.lines {
+		_ = d.writePending(line, l)
+	}
+	err := d.err
+	d.err = nil
+	d.m.Unlock()
+
+	if f, ok := d.LCD.(Flusher); ok {
+		if ferr := f.Flush(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// Close writes the pending lines first, they might be the last message.
+func (d *rateLimit) Close() error {
+	d.m.Lock()
+	for line, l := range d.lines {
+		_ = d.writePending(line, l)
+	}
+	d.m.Unlock()
+
+	return d.LCD.Close()
+}
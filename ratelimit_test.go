@@ -0,0 +1,121 @@
+package display
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newLimited(interval time.Duration) (LCD, *fakeLCD, *fakeClock) {
+	c := newFakeClock()
+	f := newFakeLCD(4, 16)
+	f.clock = c
+	return RateLimit(interval)(f), f, c
+}
+
+func TestRateLimitCoalesces(t *testing.T) {
+	lcd, f, c := newLimited(100 * time.Millisecond)
+	for _, txt := range []string{"a", "b", "c"} {
+		if err := lcd.Write(LineOne, txt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := f.written(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("got %q before the interval passed, want only the first write", got)
+	}
+	c.Advance(99 * time.Millisecond)
+	if got := f.written(); len(got) != 1 {
+		t.Fatalf("got %q before the interval passed", got)
+	}
+	c.Advance(time.Millisecond)
+	if got := f.written(); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("got %q, want the latest text once the interval passed", got)
+	}
+	c.Advance(time.Second)
+	if got := f.written(); len(got) != 2 {
+		t.Errorf("got %q, the pending write was repeated", got)
+	}
+}
+
+func TestRateLimitLinesAreIndependent(t *testing.T) {
+	lcd, f, c := newLimited(100 * time.Millisecond)
+	_ = lcd.Write(LineOne, "1a")
+	_ = lcd.Write(LineTwo, "2a")
+	c.Advance(50 * time.Millisecond)
+	_ = lcd.Write(LineTwo, "2b")
+	_ = lcd.Write(LineOne, "1b")
+	c.Advance(50 * time.Millisecond)
+	got := f.written()
+	if len(got) != 4 || got[0] != "1a" || got[1] != "2a" {
+		t.Fatalf("got %q", got)
+	}
+	if lines := f.fb.Lines(); lines[0] != fit("1b", 16) || lines[1] != fit("2b", 16) {
+		t.Errorf("got %q, want the latest text of each line", lines)
+	}
+}
+
+func TestRateLimitFlushWritesPending(t *testing.T) {
+	lcd, f, c := newLimited(time.Second)
+	_ = lcd.Write(LineOne, "a")
+	_ = lcd.Write(LineOne, "b")
+	if err := lcd.(Flusher).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.written(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("got %q, Flush didn't write the pending line", got)
+	}
+	c.Advance(time.Second)
+	if got := f.written(); len(got) != 2 {
+		t.Errorf("got %q, the flushed line was written again", got)
+	}
+}
+
+func TestRateLimitCloseDrains(t *testing.T) {
+	lcd, f, _ := newLimited(time.Second)
+	_ = lcd.Write(LineOne, "a")
+	_ = lcd.Write(LineOne, "bye")
+	if err := lcd.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.written(); !reflect.DeepEqual(got, []string{"a", "bye"}) {
+		t.Errorf("got %q, Close didn't write the pending line", got)
+	}
+}
+
+// run with -race
+func TestRateLimitConcurrentWrites(t *testing.T) {
+	lcd, f, c := newLimited(10 * time.Millisecond)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(line Line) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				_ = lcd.Write(line, fmt.Sprint(line, "/", n))
+			}
+		}(Line(i))
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			c.Advance(time.Millisecond)
+		}
+	}
+	if err := lcd.(Flusher).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for i, txt := range f.fb.Lines() {
+		if want := fit(fmt.Sprint(i, "/", 49), 16); txt != want {
+			t.Errorf("line %d is %q, want %q", i, txt, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Package render draws the content of a display as an image in the
+style of a character LCD, for support bundles and dashboards.
+*/
+package render
+
+import (
+	"github.com/artvel/display"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// Style of the rendered panel.
+type Style struct {
+	// Background of the panel with backlight on and off.
+	Background    color.Color
+	BackgroundOff color.Color
+	// On and Off are the colors of lit and unlit pixels.
+	On  color.Color
+	Off color.Color
+	// Scale is the size of a pixel, defaults to 4.
+	Scale int
+	// Columns defaults to 16.
+	Columns int
+}
+
+const (
+	// a character cell has 5x8 pixels
+	cellWidth  = 5
+	cellHeight = 8
+	// pixels between the cells and around the panel
+	cellGap = 1
+	border  = 4
+)
+
+// DefaultStyle looks like the common yellow-green panels.
+var DefaultStyle = Style{
+	Background:    color.RGBA{R: 0x9b, G: 0xc4, B: 0x0f, A: 0xff},
+	BackgroundOff: color.RGBA{R: 0x3a, G: 0x4a, B: 0x10, A: 0xff},
+	On:            color.RGBA{R: 0x20, G: 0x2a, B: 0x08, A: 0xff},
+	Off:           color.RGBA{R: 0x8b, G: 0xb4, B: 0x0f, A: 0xff},
+	Scale:         4,
+	Columns:       16,
+}
+
+// Image of the content.
+func Image(c display.Content, s Style) *image.RGBA {
+	s = s.withDefaults()
+	cols, rows := s.Columns, len(c.Lines)
+	w := (border*2 + cols*(cellWidth+cellGap) - cellGap) * s.Scale
+	h := (border*2 + rows*(cellHeight+cellGap) - cellGap) * s.Scale
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	bg, on, off := s.Background, s.On, s.Off
+	if !c.Enabled {
+		bg, on, off = s.BackgroundOff, s.BackgroundOff, s.BackgroundOff
+	}
+	fill(img, img.Bounds(), bg)
+	for row, line := range c.Lines {
+		for col := 0; col < cols; col++ {
+			ch := byte(' ')
+			if col < len(line) {
+				ch = line[col]
+			}
+			g := glyph(ch)
+			x0 := border + col*(cellWidth+cellGap)
+			y0 := border + row*(cellHeight+cellGap)
+			for x := 0; x < cellWidth; x++ {
+				for y := 0; y < cellHeight; y++ {
+					clr := off
+					if g[x]&(1<<uint(y)) != 0 {
+						clr = on
+					}
+					px := image.Rect(x0+x, y0+y, x0+x+1, y0+y+1)
+					fill(img, image.Rect(px.Min.X*s.Scale, px.Min.Y*s.Scale, px.Max.X*s.Scale-1, px.Max.Y*s.Scale-1), clr)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// PNG encodes the content as PNG.
+func PNG(w io.Writer, c display.Content, s Style) error {
+	return png.Encode(w, Image(c, s))
+}
+
+// Snapshot encodes the current content of lcd as PNG.
+func Snapshot(w io.Writer, lcd display.LCD, s Style) error {
+	c, err := display.ContentOf(lcd)
+	if err != nil {
+		return err
+	}
+	return PNG(w, c, s)
+}
+
+func (s Style) withDefaults() Style {
+	d := DefaultStyle
+	if s.Background == nil {
+		s.Background = d.Background
+	}
+	if s.BackgroundOff == nil {
+		s.BackgroundOff = d.BackgroundOff
+	}
+	if s.On == nil {
+		s.On = d.On
+	}
+	if s.Off == nil {
+		s.Off = d.Off
+	}
+	if s.Scale <= 0 {
+		s.Scale = d.Scale
+	}
+	if s.Columns <= 0 {
+		s.Columns = d.Columns
+	}
+	return s
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Set(x, y, c)
+		}
+	}
+}
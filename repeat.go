@@ -0,0 +1,106 @@
+package display
+
+import (
+	"sync"
+	"time"
+)
+
+// repeater synthesizes press events while a button is held, so menus
+// scroll on as long as a button is down.
+type repeater struct {
+	clock    Clock
+	delay    time.Duration
+	interval time.Duration
+
+	m     sync.Mutex
+	timer Timer
+	// held is the raw button repeated, 0 if none
+	held int
+	// gen is increased by every press and release, timers of an older
+	// one don't fire
+	gen int
+}
+
+const (
+	// DefaultRepeatDelay a button is held before it repeats.
+	DefaultRepeatDelay = 500 * time.Millisecond
+	// DefaultRepeatInterval between two repeats.
+	DefaultRepeatInterval = 150 * time.Millisecond
+)
+
+// WithAutoRepeat repeats the press event of a held button every
+// interval once it was held for delay, zero values use the defaults.
+// The repeated events have Repeat set. The ASUSTOR panel reports a
+// button once it was released, its buttons don't repeat.
+func WithAutoRepeat(delay, interval time.Duration) Option {
+	return func(o *options) {
+		o.autoRepeat = true
+		o.repeatDelay = delay
+		o.repeatInterval = interval
+	}
+}
+
+// newRepeater returns nil without auto-repeat, all methods accept it.
+func (o *options) newRepeater() *repeater {
+	if !o.autoRepeat {
+		return nil
+	}
+	return &repeater{
+		clock:    o.clock,
+		delay:    durationOr(o.repeatDelay, DefaultRepeatDelay),
+		interval: durationOr(o.repeatInterval, DefaultRepeatInterval),
+	}
+}
+
+// press starts repeating btn with fire. Another press of the held
+// button, as some panels report it again, doesn't restart it.
+func (r *repeater) press(btn int, fire func()) {
+	if r == nil {
+		return
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.held == btn {
+		return
+	}
+	r.stopLocked()
+	r.held = btn
+	r.schedule(r.gen, r.delay, fire)
+}
+
+// schedule must be called with the lock held. fire is called without
+// it, as it might block on a full queue while the reader releases.
+func (r *repeater) schedule(gen int, d time.Duration, fire func()) {
+	r.timer = r.clock.AfterFunc(d, func() {
+		r.m.Lock()
+		if r.gen != gen {
+			r.m.Unlock()
+			return
+		}
+		r.schedule(gen, r.interval, fire)
+		r.m.Unlock()
+
+		fire()
+	})
+}
+
+// release stops repeating, call it before passing the release on.
+func (r *repeater) release() {
+	if r == nil {
+		return
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.stopLocked()
+}
+
+func (r *repeater) stopLocked() {
+	r.gen++
+	r.held = 0
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
@@ -0,0 +1,111 @@
+package display
+
+import (
+	"github.com/artvel/display/emulator"
+	"github.com/artvel/display/qnapproto"
+	"testing"
+	"time"
+)
+
+func TestRepeaterReleasesWhileFireBlocks(t *testing.T) {
+	c := newFakeClock()
+	r := (&options{clock: c, autoRepeat: true}).newRepeater()
+	blocked, unblock := make(chan struct{}), make(chan struct{})
+	r.press(1, func() {
+		close(blocked)
+		<-unblock
+	})
+	go c.Advance(DefaultRepeatDelay)
+	<-blocked
+	released := make(chan struct{})
+	go func() {
+		r.release()
+		close(released)
+	}()
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("release waits for the blocked repeat")
+	}
+	close(unblock)
+	// the next repeat was canceled by the release
+	c.Advance(DefaultRepeatInterval)
+}
+
+// parkingClock parks the first caller of Now once park is armed until
+// resume is closed, to stop a goroutine at a given point.
+type parkingClock struct {
+	*fakeClock
+	armed  chan struct{}
+	parked chan struct{}
+	resume chan struct{}
+}
+
+func (c *parkingClock) Now() time.Time {
+	select {
+	case <-c.armed:
+		close(c.parked)
+		<-c.resume
+	default:
+	}
+	return c.fakeClock.Now()
+}
+
+func TestNoRepeatAfterTheRelease(t *testing.T) {
+	c := &parkingClock{fakeClock: newFakeClock(), armed: make(chan struct{}, 1),
+		parked: make(chan struct{}), resume: make(chan struct{})}
+	emu, con := emulator.NewQnap()
+	lcd, err := NewQnapLCDFromConn(con, WithClock(c), WithAutoRepeat(10*time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lcd.Close()
+	pressed, released := make(chan struct{}, 1), make(chan struct{}, 1)
+	cancel, _ := ObserveButtons(lcd, func(ev ButtonEvent) {
+		switch {
+		case ev.Released:
+			released <- struct{}{}
+		case !ev.Repeat:
+			pressed <- struct{}{}
+		}
+	})
+	defer cancel()
+	// the events are queued until the listener listens
+	got := make(chan ButtonEvent, 10)
+	go ListenEvents(lcd, func(ev ButtonEvent) bool {
+		got <- ev
+		return true
+	})
+
+	if err = emu.Press(qnapproto.ButtonUp); err != nil {
+		t.Fatal(err)
+	}
+	<-pressed
+	// the repeat is due and parked right before it is made
+	c.armed <- struct{}{}
+	go c.Advance(10 * time.Millisecond)
+	<-c.parked
+	if err = emu.Release(); err != nil {
+		t.Fatal(err)
+	}
+	<-released
+	close(c.resume)
+
+	var events []ButtonEvent
+	for len(events) < 2 {
+		select {
+		case ev := <-got:
+			events = append(events, ev)
+		case <-time.After(time.Second):
+			t.Fatalf("got %+v, want the press and the release", events)
+		}
+	}
+	select {
+	case ev := <-got:
+		events = append(events, ev)
+	case <-time.After(20 * time.Millisecond):
+	}
+	if len(events) != 2 || events[0].Released || events[0].Repeat || !events[1].Released {
+		t.Errorf("got %+v, want the press and the release only", events)
+	}
+}
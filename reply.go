@@ -0,0 +1,198 @@
+package display
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// matcher hands the replies read by the reader to the requests
+	// waiting for them. The panels reply in order but without a
+	// sequence number, so a request which timed out keeps its place
+	// for a few read timeouts: its late reply is discarded instead of
+	// being attributed to the next request, also to the retry of the
+	// same frame. Replies nobody waits for are reported by feed.
+	matcher struct {
+		clock Clock
+		// late is how long a timed out request waits for its reply
+		late time.Duration
+
+		m       sync.Mutex
+		pending []*pendingReply
+		// last reply matched
+		last time.Time
+		// slow is the longest a late reply took, the following
+		// requests wait that much longer
+		slow time.Duration
+	}
+	// pendingReply is a request waiting for a reply matching it.
+	pendingReply struct {
+		match func(frame []byte) bool
+		// done receives true once matched, false if canceled
+		done  chan bool
+		since time.Time
+		// expires is set once the request timed out, until then
+		// it discards its late reply
+		expires time.Time
+	}
+)
+
+// lateFactor is the multiple of the read timeout a timed out request
+// waits for its reply. It is short, as the reply of a lost request
+// would be attributed to it while it waits.
+const lateFactor = 3
+
+func newMatcher(c Clock, late time.Duration) *matcher {
+	return &matcher{clock: c, late: late}
+}
+
+// expect a reply matching match. Call it before sending the request,
+// so a fast reply isn't missed.
+func (m *matcher) expect(match func(frame []byte) bool) *pendingReply {
+	p := &pendingReply{match: match, since: m.clock.Now(), done: make(chan bool, 1)}
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	m.expire()
+	m.pending = append(m.pending, p)
+	return p
+}
+
+// wait up to d for the reply of p, longer if replies were late
+// before. As the panel replies in order, the time only counts once the
+// replies of earlier requests arrived. If the reply doesn't arrive in
+// time, p keeps its place to discard the late reply.
+func (m *matcher) wait(p *pendingReply, d time.Duration) bool {
+	m.m.Lock()
+	d += m.slow
+	m.m.Unlock()
+	start := m.clock.Now()
+	for next := d; next > 0; next = m.extend(p, start, d) {
+		select {
+		case ok := <-p.done:
+			m.settle(p)
+			return ok
+		case <-m.clock.After(next):
+		}
+	}
+	if m.settle(p) {
+		return false
+	}
+	// matched or canceled in the meantime
+	select {
+	case ok := <-p.done:
+		return ok
+	default:
+		return false
+	}
+}
+
+// extend returns how much longer p waits, 0 if it timed out. It waits
+// while earlier requests are pending and for d after the last reply,
+// up to the late timeout.
+func (m *matcher) extend(p *pendingReply, start time.Time, d time.Duration) time.Duration {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	now := m.clock.Now()
+	if now.Sub(start) >= m.late {
+		return 0
+	}
+	m.expire()
+	// earlier requests are pending until they timed out for good
+	if len(m.pending) > 0 && m.pending[0] != p {
+		return d
+	}
+	if m.last.After(start) {
+		return m.last.Add(d).Sub(now)
+	}
+	return 0
+}
+
+// settle marks p as timed out if it is still pending and reports if
+// it was.
+func (m *matcher) settle(p *pendingReply) bool {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	for _, q := range m.pending {
+		if q == p {
+			p.expires = m.clock.Now().Add(m.late)
+			return true
+		}
+	}
+	return false
+}
+
+// feed a reply to the oldest request it matches, false if none does.
+// The late reply of a timed out request is dropped.
+func (m *matcher) feed(frame []byte) bool {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	m.expire()
+	for _, p := range m.pending {
+		if p.match(frame) {
+			m.remove(p)
+			m.last = m.clock.Now()
+			if took := m.last.Sub(p.since); !p.expires.IsZero() && took > m.slow && took < m.late {
+				m.slow = took
+			}
+			p.signal(true)
+			return true
+		}
+	}
+	return false
+}
+
+// cancel stops expecting p.
+func (m *matcher) cancel(p *pendingReply) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	m.remove(p)
+}
+
+// cancelAll fails the pending requests, for example once the
+// connection is closed.
+func (m *matcher) cancelAll() {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	for _, p := range m.pending {
+		p.signal(false)
+	}
+	m.pending = nil
+}
+
+// expire must be called with the lock held, it drops the timed out
+// requests whose reply got lost.
+func (m *matcher) expire() {
+	now := m.clock.Now()
+	res := m.pending[:0]
+	for _, p := range m.pending {
+		if p.expires.IsZero() || now.Before(p.expires) {
+			res = append(res, p)
+		}
+	}
+	m.pending = res
+}
+
+// remove must be called with the lock held.
+func (m *matcher) remove(p *pendingReply) {
+	for i, q := range m.pending {
+		if q == p {
+			m.pending = append(m.pending[:i], m.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// signal the waiting request without blocking, nobody waits for the
+// late replies of completed requests.
+func (p *pendingReply) signal(ok bool) {
+	select {
+	case p.done <- ok:
+	default:
+	}
+}
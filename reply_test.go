@@ -0,0 +1,148 @@
+package display
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func matchByte(b byte) func([]byte) bool {
+	return func(f []byte) bool { return bytes.Equal(f, []byte{b}) }
+}
+
+// waitAsync waits for p in the background.
+func waitAsync(m *matcher, p *pendingReply, d time.Duration) <-chan bool {
+	res := make(chan bool, 1)
+	go func() { res <- m.wait(p, d) }()
+	return res
+}
+
+func TestMatcherReply(t *testing.T) {
+	c := newFakeClock()
+	m := newMatcher(c, time.Second)
+	p := m.expect(matchByte(1))
+	if m.feed([]byte{2}) {
+		t.Error("matched a reply nobody waits for")
+	}
+	if !m.feed([]byte{1}) {
+		t.Fatal("reply didn't match")
+	}
+	if !m.wait(p, 100*time.Millisecond) {
+		t.Error("wait failed although the reply arrived")
+	}
+}
+
+func TestMatcherTimeout(t *testing.T) {
+	c := newFakeClock()
+	m := newMatcher(c, time.Second)
+	p := m.expect(matchByte(1))
+	res := waitAsync(m, p, 100*time.Millisecond)
+	c.waiting(t, 1)
+	c.Advance(100 * time.Millisecond)
+	if <-res {
+		t.Fatal("wait succeeded without a reply")
+	}
+	// the late reply is attributed to p, not to the next request
+	next := m.expect(matchByte(1))
+	if !m.feed([]byte{1}) {
+		t.Fatal("late reply wasn't matched")
+	}
+	res = waitAsync(m, next, 100*time.Millisecond)
+	c.waiting(t, 1)
+	// the requests after a late reply wait as long as it took longer
+	c.Advance(100 * time.Millisecond)
+	select {
+	case <-res:
+		t.Fatal("next request didn't wait longer after a late reply")
+	default:
+	}
+	c.Advance(100 * time.Millisecond)
+	if <-res {
+		t.Error("late reply of the previous request completed the next one")
+	}
+}
+
+func TestMatcherDropsLostReplies(t *testing.T) {
+	c := newFakeClock()
+	m := newMatcher(c, time.Second)
+	p := m.expect(matchByte(1))
+	res := waitAsync(m, p, 100*time.Millisecond)
+	c.waiting(t, 1)
+	c.Advance(100 * time.Millisecond)
+	<-res
+	c.Advance(time.Second)
+	next := m.expect(matchByte(1))
+	if !m.feed([]byte{1}) {
+		t.Fatal("reply wasn't matched")
+	}
+	if !m.wait(next, 100*time.Millisecond) {
+		t.Error("reply was attributed to a request whose reply got lost")
+	}
+}
+
+func TestMatcherCancelAll(t *testing.T) {
+	m := newMatcher(newFakeClock(), time.Second)
+	p := m.expect(matchByte(1))
+	m.cancelAll()
+	if m.wait(p, time.Second) {
+		t.Error("canceled request succeeded")
+	}
+	if m.feed([]byte{1}) {
+		t.Error("reply matched a canceled request")
+	}
+}
+
+func TestMatcherDiscardsTheLateReplyOfARetriedRequest(t *testing.T) {
+	c := newFakeClock()
+	m := newMatcher(c, lateFactor*100*time.Millisecond)
+	first := m.expect(matchByte(1))
+	res := waitAsync(m, first, 100*time.Millisecond)
+	c.waiting(t, 1)
+	c.Advance(100 * time.Millisecond)
+	if <-res {
+		t.Fatal("wait succeeded without a reply")
+	}
+
+	retry := m.expect(matchByte(1))
+	res = waitAsync(m, retry, 100*time.Millisecond)
+	c.waiting(t, 1)
+	// the late reply of the first attempt arrives
+	c.Advance(10 * time.Millisecond)
+	if !m.feed([]byte{1}) {
+		t.Fatal("late reply wasn't expected")
+	}
+	select {
+	case <-res:
+		t.Fatal("late reply of the first attempt completed the retry")
+	default:
+	}
+	// the reply of the retry
+	c.Advance(10 * time.Millisecond)
+	if !m.feed([]byte{1}) || !<-res {
+		t.Fatal("retry didn't get its reply")
+	}
+
+	next := m.expect(matchByte(1))
+	if !m.feed([]byte{1}) || !m.wait(next, 100*time.Millisecond) {
+		t.Error("next request didn't get its reply")
+	}
+	if m.feed([]byte{1}) {
+		t.Error("a reply too many matched")
+	}
+}
+
+func TestMatcherDiscardsForAFewReadTimeoutsOnly(t *testing.T) {
+	c := newFakeClock()
+	m := newMatcher(c, lateFactor*100*time.Millisecond)
+	first := m.expect(matchByte(1))
+	res := waitAsync(m, first, 100*time.Millisecond)
+	c.waiting(t, 1)
+	c.Advance(100 * time.Millisecond)
+	<-res
+	// the reply of the first attempt got lost
+	c.Advance(lateFactor * 100 * time.Millisecond)
+	retry := m.expect(matchByte(1))
+	if !m.feed([]byte{1}) || !m.wait(retry, 100*time.Millisecond) {
+		t.Error("reply of the retry was discarded after the late timeout")
+	}
+}
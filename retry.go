@@ -0,0 +1,107 @@
+package display
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+type (
+	// RetryPolicy configures Retry.
+	RetryPolicy struct {
+		// Attempts including the first one, defaults to 3.
+		Attempts int
+		// Backoff before the second attempt, it doubles with every
+		// further attempt up to MaxBackoff. Defaults to 100ms.
+		Backoff time.Duration
+		// MaxBackoff defaults to 2 seconds.
+		MaxBackoff time.Duration
+		// Jitter randomizes every pause by up to this fraction,
+		// defaults to 0.5. A negative value disables it.
+		Jitter float64
+		// Retryable reports if an error is transient. By default all
+		// errors are, except for ErrClosed, ErrReadOnly, ErrNotSupported
+		// and ErrUnsupportedLine.
+		Retryable func(err error) bool
+	}
+
+	// RetryError is returned by Retry once it gave up.
+	RetryError struct {
+		Attempts int
+		// Err of the last attempt.
+		Err error
+	}
+
+	retry struct {
+		decorator
+		p     RetryPolicy
+		clock Clock
+	}
+)
+
+// Retry decorates lcd retrying failed writes and Enable calls with a
+// jittered exponential backoff, for example to ride out the hiccups of
+// the QNAP link. Permanent errors are returned right away.
+func Retry(lcd LCD, p RetryPolicy) LCD {
+	if p.Attempts <= 0 {
+		p.Attempts = 3
+	}
+	p.Backoff = durationOr(p.Backoff, 100*time.Millisecond)
+	p.MaxBackoff = durationOr(p.MaxBackoff, 2*time.Second)
+	if p.Jitter == 0 {
+		p.Jitter = 0.5
+	}
+	if p.Retryable == nil {
+		p.Retryable = transient
+	}
+	return &retry{decorator: decorator{lcd}, p: p, clock: ClockOf(lcd)}
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("display: giving up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+func transient(err error) bool {
+	switch err {
+	case ErrClosed, ErrReadOnly, ErrNotSupported, ErrUnsupportedLine:
+		return false
+	}
+	return true
+}
+
+func (r *retry) Write(line Line, text string) error {
+	return r.do(func() error { return r.LCD.Write(line, text) })
+}
+
+func (r *retry) Enable(yes bool) error {
+	return r.do(func() error { return r.LCD.Enable(yes) })
+}
+
+func (r *retry) do(fn func() error) error {
+	backoff := r.p.Backoff
+	for i := 1; ; i++ {
+		err := fn()
+		if err == nil || !r.p.Retryable(err) {
+			return err
+		}
+		if i == r.p.Attempts {
+			return &RetryError{Attempts: i, Err: err}
+		}
+		r.clock.Sleep(r.jitter(backoff))
+		if backoff *= 2; backoff > r.p.MaxBackoff {
+			backoff = r.p.MaxBackoff
+		}
+	}
+}
+
+// jitter d by up to the configured fraction in both directions.
+func (r *retry) jitter(d time.Duration) time.Duration {
+	if r.p.Jitter <= 0 {
+		return d
+	}
+	return d + time.Duration((rand.Float64()*2-1)*r.p.Jitter*float64(d))
+}
@@ -0,0 +1,91 @@
+package display
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// flaky fails writes with the queued errors and records when it was tried.
+type flaky struct {
+	decorator
+	c     *fakeClock
+	start time.Time
+	errs  []error
+	at    []time.Duration
+}
+
+func newFlaky(errs ...error) *flaky {
+	c := newFakeClock()
+	return &flaky{decorator: decorator{NewLoggingDummy(ioutil.Discard, WithClock(c))}, c: c, start: c.Now(), errs: errs}
+}
+
+func (f *flaky) Write(line Line, text string) error {
+	f.at = append(f.at, f.c.Now().Sub(f.start))
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func TestRetry(t *testing.T) {
+	errLink := errors.New("link")
+	tests := []struct {
+		name     string
+		errs     []error
+		attempts int
+		at       []time.Duration
+		want     error
+	}{
+		{"first", nil, 3, []time.Duration{0}, nil},
+		{"second", []error{errLink}, 3, []time.Duration{0, 100 * time.Millisecond}, nil},
+		{"doubles", []error{errLink, errLink}, 3, []time.Duration{0, 100 * time.Millisecond, 300 * time.Millisecond}, nil},
+		{"capped", []error{errLink, errLink, errLink}, 4, []time.Duration{0, 100 * time.Millisecond, 300 * time.Millisecond, 550 * time.Millisecond}, nil},
+		{"gives up", []error{errLink, errLink, errLink}, 3, []time.Duration{0, 100 * time.Millisecond, 300 * time.Millisecond}, errLink},
+		{"permanent", []error{ErrReadOnly}, 3, []time.Duration{0}, ErrReadOnly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFlaky(tt.errs...)
+			r := Retry(f, RetryPolicy{Attempts: tt.attempts, MaxBackoff: 250 * time.Millisecond, Jitter: -1})
+			err := r.Write(LineOne, "x")
+			if !errors.Is(err, tt.want) || (err == nil) != (tt.want == nil) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if len(f.at) != len(tt.at) {
+				t.Fatalf("tried %d times, want %d", len(f.at), len(tt.at))
+			}
+			for i, at := range f.at {
+				if at != tt.at[i] {
+					t.Errorf("attempt %d at %v, want %v", i+1, at, tt.at[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRetryError(t *testing.T) {
+	errLink := errors.New("link")
+	err := Retry(newFlaky(errLink, errLink), RetryPolicy{Attempts: 2, Jitter: -1}).Write(LineOne, "x")
+	var re *RetryError
+	if !errors.As(err, &re) {
+		t.Fatalf("got %T, want *RetryError", err)
+	}
+	if re.Attempts != 2 || re.Err != errLink || errors.Unwrap(err) != errLink {
+		t.Errorf("got %+v", re)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	for _, err := range []error{ErrClosed, ErrReadOnly, ErrNotSupported, ErrUnsupportedLine} {
+		if transient(err) {
+			t.Errorf("%v is transient", err)
+		}
+	}
+	if !transient(errors.New("timeout")) {
+		t.Error("other errors aren't transient")
+	}
+}
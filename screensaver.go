@@ -0,0 +1,193 @@
+package display
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// Screensaver turns the display off after a while without button
+	// activity and on again with the next press. Writes while it is
+	// off follow the DisabledPolicy of the driver. Screensaver wraps
+	// the display, use it instead of the display itself.
+	Screensaver struct {
+		LCD
+		idle  time.Duration
+		clock Clock
+		// swallowWake keeps the press waking the display from the
+		// listeners
+		swallowWake bool
+		// observed is set if the wrapped display reports every button
+		// event, not only the ones passing the listeners
+		observed bool
+		cancel   func()
+
+		m      sync.Mutex
+		asleep bool
+		// swallowed is the raw button which woke the display if
+		// swallowing, its repeats and release are swallowed too
+		swallowing bool
+		swallowed  int
+
+		// tm guards the idle time, it is taken by the observer on
+		// the reader of the driver, so it's never held while calling
+		// the display
+		tm    sync.Mutex
+		timer Timer
+		last  time.Time
+	}
+	// ScreensaverOption configures a Screensaver.
+	ScreensaverOption func(*Screensaver)
+)
+
+// WithSwallowWake keeps the press waking the display from the
+// listeners, so it doesn't act on a screen the user couldn't see.
+// Otherwise the press is delivered as well.
+func WithSwallowWake() ScreensaverOption {
+	return func(s *Screensaver) {
+		s.swallowWake = true
+	}
+}
+
+// NewScreensaver turns lcd off after idle without a button event.
+func NewScreensaver(lcd LCD, idle time.Duration, opts ...ScreensaverOption) *Screensaver {
+	c := ClockOf(lcd)
+	s := &Screensaver{LCD: lcd, idle: idle, clock: c, last: c.Now()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.timer = c.AfterFunc(idle, s.sleep)
+	s.cancel, s.observed = ObserveButtons(lcd, func(ButtonEvent) {
+		s.touch()
+	})
+	return s
+}
+
+func (s *Screensaver) Unwrap() LCD {
+	return s.LCD
+}
+
+// Asleep reports if the screensaver turned the display off.
+func (s *Screensaver) Asleep() bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.asleep
+}
+
+// Wake turns the display on and restarts the idle time, for example
+// on an alert.
+func (s *Screensaver) Wake() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.wake()
+}
+
+// Enable the display, enabling it wakes the screensaver as well.
+func (s *Screensaver) Enable(yes bool) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if yes {
+		s.asleep = false
+		s.touch()
+	}
+	return s.LCD.Enable(yes)
+}
+
+// Close stops the screensaver and closes the display.
+func (s *Screensaver) Close() error {
+	s.cancel()
+	s.tm.Lock()
+	s.timer.Stop()
+	s.tm.Unlock()
+
+	return s.LCD.Close()
+}
+
+func (s *Screensaver) Listen(fn func(btn int, released bool) bool) {
+	s.ListenEvents(func(ev ButtonEvent) bool {
+		return fn(ev.Raw, ev.Released)
+	})
+}
+
+func (s *Screensaver) ListenEvents(fn func(ev ButtonEvent) bool) {
+	s.ListenEventsContext(context.Background(), fn)
+}
+
+func (s *Screensaver) ListenEventsContext(ctx context.Context, fn func(ev ButtonEvent) bool) {
+	ListenEventsContext(ctx, s.LCD, func(ev ButtonEvent) bool {
+		if s.pass(ev) {
+			return fn(ev)
+		}
+		return true
+	})
+}
+
+// pass reports if ev may reach the listeners and wakes the display
+// on a press.
+func (s *Screensaver) pass(ev ButtonEvent) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if !s.observed {
+		s.touch()
+	}
+	if s.swallowing && ev.Raw == s.swallowed {
+		if ev.Released {
+			s.swallowing = false
+		}
+		return false
+	}
+	if !s.asleep || ev.Released {
+		return true
+	}
+	_ = s.wake()
+	if s.swallowWake {
+		s.swallowing, s.swallowed = true, ev.Raw
+		return false
+	}
+	return true
+}
+
+// wake must be called with the lock held.
+func (s *Screensaver) wake() error {
+	s.touch()
+	if !s.asleep {
+		return nil
+	}
+	s.asleep = false
+	return s.LCD.Enable(true)
+}
+
+// touch restarts the idle time.
+func (s *Screensaver) touch() {
+	s.tm.Lock()
+	defer s.tm.Unlock()
+
+	s.last = s.clock.Now()
+	s.timer.Stop()
+	s.timer = s.clock.AfterFunc(s.idle, s.sleep)
+}
+
+func (s *Screensaver) sleep() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// there was activity while the timer fired
+	if s.asleep || s.idleFor() < s.idle {
+		return
+	}
+	if err := s.LCD.Enable(false); err == nil {
+		s.asleep = true
+	}
+}
+
+func (s *Screensaver) idleFor() time.Duration {
+	s.tm.Lock()
+	defer s.tm.Unlock()
+
+	return s.clock.Now().Sub(s.last)
+}
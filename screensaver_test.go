@@ -0,0 +1,126 @@
+package display
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// observedLCD passes the events to its observers before the
+// listeners get them, like the drivers do.
+type observedLCD struct {
+	*fakeLCD
+	om        sync.Mutex
+	observers []func(ev ButtonEvent)
+}
+
+func (o *observedLCD) ObserveButtons(fn func(ev ButtonEvent)) (cancel func()) {
+	o.om.Lock()
+	defer o.om.Unlock()
+
+	o.observers = append(o.observers, fn)
+	return func() {}
+}
+
+func (o *observedLCD) ObserveContent(fn func(c Content)) (cancel func()) {
+	return o.fb.Observe(fn)
+}
+
+func (o *observedLCD) press(ev ButtonEvent) {
+	o.om.Lock()
+	for _, fn := range o.observers {
+		fn(ev)
+	}
+	o.om.Unlock()
+	o.events <- ev
+}
+
+func TestScreensaverSleepsAfterTheIdleTime(t *testing.T) {
+	c := newFakeClock()
+	lcd := &observedLCD{fakeLCD: newFakeLCD(2, 16)}
+	lcd.clock = c
+	s := NewScreensaver(lcd, time.Minute)
+	defer s.Close()
+
+	c.Advance(30 * time.Second)
+	// nobody listens, the press is observed on the display
+	lcd.press(ButtonEvent{Button: ButtonUp, Raw: 1})
+	c.Advance(59 * time.Second)
+	if s.Asleep() {
+		t.Fatal("the press didn't restart the idle time")
+	}
+	c.Advance(time.Second)
+	if !s.Asleep() || lcd.fb.Content().Enabled {
+		t.Error("screensaver didn't turn the display off")
+	}
+}
+
+func TestScreensaverWakesOnPress(t *testing.T) {
+	tests := []struct {
+		name    string
+		lcd     func(f *fakeLCD) LCD
+		opts    []ScreensaverOption
+		entered []int
+	}{
+		{"delivered", func(f *fakeLCD) LCD { return f }, nil, []int{0, 0, 2}},
+		{"swallowed", func(f *fakeLCD) LCD { return f }, []ScreensaverOption{WithSwallowWake()}, []int{2}},
+		{"swallowed while observed", func(f *fakeLCD) LCD { return &observedLCD{fakeLCD: f} }, []ScreensaverOption{WithSwallowWake()}, []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFakeClock()
+			f := newFakeLCD(2, 16)
+			f.clock = c
+			lcd := tt.lcd(f)
+			s := NewScreensaver(lcd, time.Minute, tt.opts...)
+			defer s.Close()
+			c.Advance(time.Minute)
+			if !s.Asleep() {
+				t.Fatal("screensaver didn't sleep")
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			got := make(chan ButtonEvent, 8)
+			go s.ListenEventsContext(ctx, func(ev ButtonEvent) bool {
+				got <- ev
+				return true
+			})
+			send := func(ev ButtonEvent) {
+				if o, ok := lcd.(*observedLCD); ok {
+					o.press(ev)
+				} else {
+					f.events <- ev
+				}
+			}
+			// raw button 0 exists on some panels
+			send(ButtonEvent{Button: ButtonUp, Raw: 0})
+			send(ButtonEvent{Button: ButtonUp, Raw: 0, Repeat: true})
+			send(ButtonEvent{Button: ButtonUp, Raw: 0, Released: true})
+			send(ButtonEvent{Button: ButtonDown, Raw: 2})
+
+			var entered []int
+			for ev := range got {
+				if !ev.Repeat {
+					entered = append(entered, ev.Raw)
+				}
+				if ev.Raw == 2 {
+					break
+				}
+			}
+			if !reflect.DeepEqual(entered, tt.entered) {
+				t.Errorf("listeners got %v, want %v", entered, tt.entered)
+			}
+			if s.Asleep() || !f.fb.Content().Enabled {
+				t.Error("the press didn't wake the display")
+			}
+			// the swallowed events count as activity
+			c.Advance(59 * time.Second)
+			if s.Asleep() {
+				t.Error("the press didn't restart the idle time")
+			}
+		})
+	}
+}
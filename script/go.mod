@@ -0,0 +1,10 @@
+module github.com/artvel/display/script
+
+go 1.16
+
+replace github.com/artvel/display => ../
+
+require (
+	github.com/artvel/display v0.0.0-00010101000000-000000000000
+	go.starlark.net v0.0.0-20230525235612-a134d8f9ddca
+)
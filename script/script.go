@@ -0,0 +1,279 @@
+// Package script runs status pages and button handlers written in
+// Starlark, a small Python dialect, so the panel can be customized
+// without writing Go. The script is reloaded once the file changed.
+//
+//	name = "Backup"
+//	interval = "30s"
+//
+//	def page():
+//	    return ["Backup", read_file("/var/run/backup.status")]
+//
+//	def on_button(button, held):
+//	    if button == "enter":
+//	        out = exec("backup-now")
+//	        toast(["Backup started", out], seconds = 5)
+//
+// page returns the lines of the page, on_button is called with the
+// name of every released button, like up or enter, and how many
+// seconds it was held. Both are optional. The builtins are
+// exec(cmd, *args) returning the output, read_file(path) and
+// toast(lines, seconds = 10, priority = 10, flash = False, id = "").
+// print logs.
+//
+// It is a module of its own to keep Starlark out of the dependencies
+// of the display package.
+package script
+
+import (
+	"context"
+	"fmt"
+	"github.com/artvel/display"
+	"github.com/artvel/display/pages"
+	"go.starlark.net/starlark"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Script is a pages.DataSource running the page function of a script.
+type Script struct {
+	// Path of the script file.
+	Path string
+	// Board receives the toasts of the script, they are dropped if nil.
+	Board *pages.Board
+
+	m       sync.Mutex
+	mod     time.Time
+	globals starlark.StringDict
+	toasts  int
+}
+
+// ctxKey of the thread local context of the builtins
+const ctxKey = "ctx"
+
+// Name is the name global of the script or its file name.
+func (s *Script) Name() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	_ = s.reload()
+	if name, ok := starlark.AsString(s.globals["name"]); ok {
+		return name
+	}
+	return strings.TrimSuffix(filepath.Base(s.Path), filepath.Ext(s.Path))
+}
+
+// Interval is the interval global of the script, like "30s". It is
+// read once when the scheduler starts.
+func (s *Script) Interval() time.Duration {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	_ = s.reload()
+	if v, ok := starlark.AsString(s.globals["interval"]); ok {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			return d
+		}
+		log.Printf("script %s: interval: %v", s.Path, err)
+	}
+	return 0
+}
+
+// Poll calls the page function.
+func (s *Script) Poll(ctx context.Context) ([]string, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	fn, ok := s.globals["page"]
+	if !ok {
+		return nil, nil
+	}
+	res, err := s.call(ctx, fn)
+	if err != nil {
+		return nil, err
+	}
+	return lines(res)
+}
+
+// Watch calls on_button for every released button of lcd until ctx
+// is done.
+func (s *Script) Watch(ctx context.Context, lcd display.LCD) {
+	handle := func(ev display.ButtonEvent) {
+		if !ev.Released {
+			return
+		}
+		s.m.Lock()
+		defer s.m.Unlock()
+
+		if err := s.reload(); err != nil {
+			return
+		}
+		fn, ok := s.globals["on_button"]
+		if !ok {
+			return
+		}
+		if _, err := s.call(ctx, fn, starlark.String(ev.Button.String()), starlark.Float(ev.Held.Seconds())); err != nil {
+			log.Printf("script %s: on_button: %v", s.Path, err)
+		}
+	}
+	cancel, ok := display.ObserveButtons(lcd, handle)
+	defer cancel()
+	if !ok {
+		display.ListenEventsContext(ctx, lcd, func(ev display.ButtonEvent) bool {
+			handle(ev)
+			return true
+		})
+	}
+	<-ctx.Done()
+}
+
+// reload the script if the file changed, must be called with the
+// lock held.
+func (s *Script) reload() error {
+	fi, err := os.Stat(s.Path)
+	if err != nil {
+		return err
+	}
+	if s.globals != nil && fi.ModTime().Equal(s.mod) {
+		return nil
+	}
+	thread := s.thread(context.Background())
+	globals, err := starlark.ExecFile(thread, s.Path, nil, s.builtins())
+	if err != nil {
+		// keep the previous version running
+		log.Printf("script: %v", err)
+		if s.globals == nil {
+			return err
+		}
+		return nil
+	}
+	s.mod, s.globals = fi.ModTime(), globals
+	return nil
+}
+
+func (s *Script) call(ctx context.Context, fn starlark.Value, args ...starlark.Value) (starlark.Value, error) {
+	thread := s.thread(ctx)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+	return starlark.Call(thread, fn, args, nil)
+}
+
+func (s *Script) thread(ctx context.Context) *starlark.Thread {
+	t := &starlark.Thread{
+		Name: s.Path,
+		Print: func(_ *starlark.Thread, msg string) {
+			log.Printf("script %s: %s", s.Path, msg)
+		},
+	}
+	t.SetLocal(ctxKey, ctx)
+	return t
+}
+
+func (s *Script) builtins() starlark.StringDict {
+	return starlark.StringDict{
+		"exec":      starlark.NewBuiltin("exec", builtinExec),
+		"read_file": starlark.NewBuiltin("read_file", builtinReadFile),
+		"toast":     starlark.NewBuiltin("toast", s.builtinToast),
+	}
+}
+
+func builtinExec(t *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) == 0 || len(kwargs) > 0 {
+		return nil, fmt.Errorf("%s: want a command and its arguments", b.Name())
+	}
+	cmd := make([]string, len(args))
+	for i, a := range args {
+		s, ok := starlark.AsString(a)
+		if !ok {
+			return nil, fmt.Errorf("%s: argument %d is not a string", b.Name(), i+1)
+		}
+		cmd[i] = s
+	}
+	ctx, _ := t.Local(ctxKey).(context.Context)
+	out, err := exec.CommandContext(ctx, cmd[0], cmd[1:]...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", b.Name(), err)
+	}
+	return starlark.String(strings.TrimRight(string(out), "\n")), nil
+}
+
+func builtinReadFile(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var path string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "path", &path); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", b.Name(), err)
+	}
+	return starlark.String(strings.TrimRight(string(content), "\n")), nil
+}
+
+func (s *Script) builtinToast(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		list     starlark.Value
+		seconds  = 10.0
+		priority = int(pages.PriorityHigh)
+		flash    bool
+		id       string
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "lines", &list, "seconds?", &seconds, "priority?", &priority, "flash?", &flash, "id?", &id); err != nil {
+		return nil, err
+	}
+	l, err := lines(list)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", b.Name(), err)
+	}
+	if id == "" {
+		s.toasts++
+		id = fmt.Sprint(s.toasts)
+	}
+	if s.Board != nil {
+		s.Board.Show(pages.Toast{
+			ID:       "script/" + s.Path + "/" + id,
+			Lines:    l,
+			Priority: pages.Priority(priority),
+			Duration: time.Duration(seconds * float64(time.Second)),
+			Flash:    flash,
+		})
+	}
+	return starlark.None, nil
+}
+
+// lines of a list or tuple of strings, None means no lines.
+func lines(v starlark.Value) ([]string, error) {
+	if v == starlark.None {
+		return nil, nil
+	}
+	it, ok := v.(starlark.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("want a list of lines, got %s", v.Type())
+	}
+	iter := it.Iterate()
+	defer iter.Done()
+	var res []string
+	var x starlark.Value
+	for iter.Next(&x) {
+		s, ok := starlark.AsString(x)
+		if !ok {
+			s = x.String()
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
@@ -0,0 +1,97 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type (
+	// SelfTestStep is the outcome of a single step of SelfTest.
+	// Err is nil if the display acknowledged the step.
+	SelfTestStep struct {
+		Name string
+		Err  error
+	}
+	// SelfTestReport lists all steps in the order they ran.
+	SelfTestReport []SelfTestStep
+)
+
+// SelfTest cycles a test pattern on the display to diagnose
+// dead pixels or flaky serial links. It fills all cells, shows a
+// checkerboard and its inversion, addresses each line on its own
+// and toggles the backlight.
+// Every step stays visible for the pause duration.
+// The display is left blank and enabled afterwards.
+func SelfTest(lcd LCD, pause time.Duration) SelfTestReport {
+	width := WidthOf(lcd)
+	checker := strings.Repeat(filledSquare+" ", (width+1)/2)[:width]
+	inverted := strings.Repeat(" "+filledSquare, (width+1)/2)[:width]
+	filled := strings.Repeat(filledSquare, width)
+	lines := make([]Line, LineCountOf(lcd))
+	for i := range lines {
+		lines[i] = Line(i)
+	}
+
+	c := ClockOf(lcd)
+	var report SelfTestReport
+	step := func(name string, fn func() error) {
+		report = append(report, SelfTestStep{Name: name, Err: fn()})
+		c.Sleep(pause)
+	}
+	// writeAll writes even and odd lines with the texts
+	writeAll := func(even, odd string) func() error {
+		return func() error {
+			for _, line := range lines {
+				text := even
+				if line%2 == 1 {
+					text = odd
+				}
+				if err := lcd.Write(line, text); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	step("enable", func() error { return lcd.Enable(true) })
+	step("fill", writeAll(filled, filled))
+	step("checkerboard", writeAll(checker, inverted))
+	step("inverted checkerboard", writeAll(inverted, checker))
+	for i, line := range lines {
+		line, text := line, fmt.Sprintf("line %d", i+1)
+		step(text, func() error {
+			if err := writeAll("", "")(); err != nil {
+				return err
+			}
+			return lcd.Write(line, text)
+		})
+	}
+	step("backlight off", func() error { return lcd.Enable(false) })
+	step("backlight on", func() error { return lcd.Enable(true) })
+	report = append(report, SelfTestStep{Name: "clear", Err: writeAll("", "")()})
+	return report
+}
+
+// OK is true if every step was acknowledged.
+func (r SelfTestReport) OK() bool {
+	for _, s := range r {
+		if s.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (r SelfTestReport) String() string {
+	b := strings.Builder{}
+	for _, s := range r {
+		status := "ok"
+		if s.Err != nil {
+			status = s.Err.Error()
+		}
+		b.WriteString(fmt.Sprintf("%-22s %s\n", s.Name, status))
+	}
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package display
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelfTestUsesTheSizeOfTheDisplay(t *testing.T) {
+	lcd := newFakeLCD(4, 20)
+	report := SelfTest(lcd, 0)
+	if !report.OK() {
+		t.Fatalf("self test failed:\n%s", report)
+	}
+	filled := strings.Repeat(filledSquare, 20)
+	fills := 0
+	for _, w := range lcd.written() {
+		if len(w) > 20 {
+			t.Errorf("%q is wider than the display", w)
+		}
+		if w == filled {
+			fills++
+		}
+	}
+	if fills != 4 {
+		t.Errorf("filled %d lines, want 4", fills)
+	}
+	if got := len(report); got != 11 {
+		t.Errorf("%d steps, want 11 with a step for every line", got)
+	}
+}
@@ -0,0 +1,210 @@
+package display
+
+import (
+	"fmt"
+	"github.com/chmorgan/go-serial2/serial"
+	"io"
+	"sync"
+	"time"
+)
+
+type (
+	// SerialConfig holds the line settings a display needs.
+	SerialConfig struct {
+		PortName        string
+		BaudRate        uint
+		DataBits        uint
+		StopBits        uint
+		MinimumReadSize uint
+		Parity          Parity
+		// RTSCTSFlowControl enables hardware flow control.
+		RTSCTSFlowControl bool
+		// Rs485Enable switches the port to RS-485 mode, only
+		// supported by some Linux drivers. The other Rs485 settings
+		// apply to it.
+		Rs485Enable             bool
+		Rs485RtsHighDuringSend  bool
+		Rs485RtsHighAfterSend   bool
+		Rs485RxDuringTx         bool
+		Rs485DelayRtsBeforeSend time.Duration
+		Rs485DelayRtsAfterSend  time.Duration
+	}
+
+	// Parity of the serial line.
+	Parity int
+
+	// RS485 are the settings of a port in RS-485 mode, as needed by
+	// panels attached through an industrial converter.
+	RS485 struct {
+		// RTSHighDuringSend and RTSHighAfterSend set the logic level
+		// of RTS, which switches the direction of the transceiver.
+		RTSHighDuringSend bool
+		RTSHighAfterSend  bool
+		// RxDuringTx receives the own frames while sending.
+		RxDuringTx bool
+		// DelayRTSBeforeSend and DelayRTSAfterSend are rounded to
+		// milliseconds.
+		DelayRTSBeforeSend time.Duration
+		DelayRTSAfterSend  time.Duration
+	}
+	// SerialOpener opens serial ports for the drivers.
+	// Implement it to replace the default go-serial2 backend,
+	// for example with go.bug.st/serial or tarm/serial on platforms
+	// the default one doesn't support. The PortName is passed as
+	// given, it might be a network address.
+	SerialOpener interface {
+		OpenSerial(c SerialConfig) (io.ReadWriteCloser, error)
+	}
+	// SerialOpenerFunc adapts a function to a SerialOpener.
+	SerialOpenerFunc func(c SerialConfig) (io.ReadWriteCloser, error)
+
+	goSerial2 struct{}
+
+	// lockedPort releases the port lock on close.
+	lockedPort struct {
+		io.ReadWriteCloser
+		unlock func()
+		once   sync.Once
+	}
+)
+
+const (
+	ParityNone Parity = iota
+	ParityOdd
+	ParityEven
+)
+
+// DefaultSerialOpener is backed by github.com/chmorgan/go-serial2.
+var DefaultSerialOpener SerialOpener = goSerial2{}
+
+// WithSerialOpener replaces the DefaultSerialOpener.
+func WithSerialOpener(s SerialOpener) Option {
+	return func(o *options) {
+		o.serialOpener = s
+	}
+}
+
+// WithParity overrides the parity of the serial line, none by default.
+func WithParity(p Parity) Option {
+	return func(o *options) {
+		o.parity = &p
+	}
+}
+
+// WithStopBits overrides the stop bits of the serial line, 1 or 2.
+func WithStopBits(n uint) Option {
+	return func(o *options) {
+		o.stopBits = n
+	}
+}
+
+// WithDataBits overrides the data bits of the serial line, 5 to 8.
+func WithDataBits(n uint) Option {
+	return func(o *options) {
+		o.dataBits = n
+	}
+}
+
+// WithFlowControl turns RTS/CTS hardware flow control on or off.
+func WithFlowControl(rtscts bool) Option {
+	return func(o *options) {
+		o.flowControl = &rtscts
+	}
+}
+
+// WithRS485 switches the port to RS-485 mode with r, replacing the
+// defaults of the driver.
+func WithRS485(r RS485) Option {
+	return func(o *options) {
+		o.rs485 = &r
+	}
+}
+
+func (p Parity) String() string {
+	switch p {
+	case ParityNone:
+		return "none"
+	case ParityOdd:
+		return "odd"
+	case ParityEven:
+		return "even"
+	}
+	return fmt.Sprintf("Parity(%d)", int(p))
+}
+
+func (f SerialOpenerFunc) OpenSerial(c SerialConfig) (io.ReadWriteCloser, error) {
+	return f(c)
+}
+
+// OpenSerial takes an advisory lock on the port first, so two processes
+// don't corrupt each other's frames. It fails with ErrPortBusy if the
+// port is locked by another process. Short names like ttyS1 or com3 are
+// accepted.
+func (goSerial2) OpenSerial(c SerialConfig) (io.ReadWriteCloser, error) {
+	c.PortName = normalizePort(c.PortName)
+	unlock, err := lockPort(c.PortName)
+	if err != nil {
+		return nil, err
+	}
+	con, err := serial.Open(serial.OpenOptions{
+		PortName:        c.PortName,
+		BaudRate:        c.BaudRate,
+		DataBits:        c.DataBits,
+		StopBits:        c.StopBits,
+		MinimumReadSize: c.MinimumReadSize,
+		// the values are the same
+		ParityMode:              serial.ParityMode(c.Parity),
+		RTSCTSFlowControl:       c.RTSCTSFlowControl,
+		Rs485Enable:             c.Rs485Enable,
+		Rs485RtsHighDuringSend:  c.Rs485RtsHighDuringSend,
+		Rs485RtsHighAfterSend:   c.Rs485RtsHighAfterSend,
+		Rs485RxDuringTx:         c.Rs485RxDuringTx,
+		Rs485DelayRtsBeforeSend: int(c.Rs485DelayRtsBeforeSend / time.Millisecond),
+		Rs485DelayRtsAfterSend:  int(c.Rs485DelayRtsAfterSend / time.Millisecond),
+	})
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	return &lockedPort{ReadWriteCloser: con, unlock: unlock}, nil
+}
+
+func (l *lockedPort) Close() error {
+	err := l.ReadWriteCloser.Close()
+	l.once.Do(l.unlock)
+	return err
+}
+
+// serialConnector opens the port with the configured SerialOpener.
+func (o *options) serialConnector(c SerialConfig) connector {
+	opener := o.serialOpener
+	if opener == nil {
+		opener = DefaultSerialOpener
+	}
+	if o.baudRate > 0 {
+		c.BaudRate = o.baudRate
+	}
+	if o.dataBits > 0 {
+		c.DataBits = o.dataBits
+	}
+	if o.stopBits > 0 {
+		c.StopBits = o.stopBits
+	}
+	if o.parity != nil {
+		c.Parity = *o.parity
+	}
+	if o.flowControl != nil {
+		c.RTSCTSFlowControl = *o.flowControl
+	}
+	if r := o.rs485; r != nil {
+		c.Rs485Enable = true
+		c.Rs485RtsHighDuringSend = r.RTSHighDuringSend
+		c.Rs485RtsHighAfterSend = r.RTSHighAfterSend
+		c.Rs485RxDuringTx = r.RxDuringTx
+		c.Rs485DelayRtsBeforeSend = r.DelayRTSBeforeSend
+		c.Rs485DelayRtsAfterSend = r.DelayRTSAfterSend
+	}
+	return func() (io.ReadWriteCloser, error) {
+		return opener.OpenSerial(c)
+	}
+}
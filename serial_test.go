@@ -0,0 +1,32 @@
+package display
+
+import (
+	"net"
+	"testing"
+)
+
+func TestSerialConnectorKeepsNetworkAddresses(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan error, 1)
+	go func() {
+		con, err := ln.Accept()
+		if err == nil {
+			_ = con.Close()
+		}
+		accepted <- err
+	}()
+
+	o := newOptions([]Option{WithSerialOpener(TCPOpener{})})
+	con, err := o.serialConnector(SerialConfig{PortName: ln.Addr().String()})()
+	if err != nil {
+		t.Fatalf("dialing the port name failed: %v", err)
+	}
+	defer con.Close()
+	if err = <-accepted; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,83 @@
+package display
+
+import (
+	"strings"
+	"time"
+)
+
+// freezer is implemented by displays which can ignore all further
+// writes, so a final message stays until the power is gone.
+type freezer interface {
+	freeze()
+}
+
+// time between the frames of the boot splash
+const splashStep = 60 * time.Millisecond
+
+// ShowBootSplash turns the display on and shows name with a short
+// animation, followed by the version. Meant to be called early during
+// boot, like from an init script.
+func ShowBootSplash(lcd LCD, name, version string) error {
+	if err := lcd.Enable(true); err != nil {
+		return err
+	}
+	width := WidthOf(lcd)
+	if err := lcd.Write(LineOne, center(name, width)); err != nil {
+		return err
+	}
+	const steps = 8
+	for i := 1; i <= steps; i++ {
+		bar := strings.Repeat(CurrentTheme().Filled, i*width/steps/2)
+		if err := lcd.Write(LineTwo, center(bar, width)); err != nil {
+			return err
+		}
+		ClockOf(lcd).Sleep(splashStep)
+	}
+	if version != "" {
+		version = "v" + strings.TrimPrefix(version, "v")
+	}
+	return lcd.Write(LineTwo, center(version, width))
+}
+
+// ShowShutdown shows the final message, MsgShuttingDown by default,
+// and makes the display ignore all further writes and Enable calls,
+// so nothing overwrites it before the serial port goes away.
+// Pending writes are flushed first and the message afterwards.
+func ShowShutdown(lcd LCD, lines ...string) error {
+	if len(lines) == 0 {
+		lines = []string{Tr(MsgShuttingDown)}
+	}
+	_ = flush(lcd)
+	if err := lcd.Enable(true); err != nil {
+		return err
+	}
+	for i, n := 0, LineCountOf(lcd); i < n; i++ {
+		txt := ""
+		if i < len(lines) {
+			txt = lines[i]
+		}
+		if err := lcd.Write(Line(i), txt); err != nil {
+			return err
+		}
+	}
+	if err := flush(lcd); err != nil {
+		return err
+	}
+	for _, l := range chain(lcd) {
+		if f, ok := l.(freezer); ok {
+			f.freeze()
+		}
+	}
+	return nil
+}
+
+// flush the outermost Flusher lcd wraps, it flushes the ones
+// it wraps itself.
+func flush(lcd LCD) error {
+	for _, l := range chain(lcd) {
+		if f, ok := l.(Flusher); ok {
+			return f.Flush()
+		}
+	}
+	return nil
+}
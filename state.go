@@ -0,0 +1,38 @@
+package display
+
+// State of a display as saved by SaveState.
+type State struct {
+	Lines   []string
+	Enabled bool
+}
+
+// SaveState of lcd, so a subsystem like a menu can take over the display
+// and hand it back with RestoreState. The devices have no controllable
+// backlight level, it is covered by Enabled. Displays without a
+// framebuffer return ErrNotSupported.
+func SaveState(lcd LCD) (State, error) {
+	c, err := ContentOf(lcd)
+	if err != nil {
+		return State{}, err
+	}
+	return State{Lines: c.Lines, Enabled: c.Enabled}, nil
+}
+
+// RestoreState of lcd as returned by SaveState.
+// Only lines which changed in between are written.
+func RestoreState(lcd LCD, s State) error {
+	fb, ok := FramebufferOf(lcd)
+	if !ok {
+		return ErrNotSupported
+	}
+	for _, line := range fb.Diff(s.Lines) {
+		if err := lcd.Write(line, s.Lines[line]); err != nil {
+			return err
+		}
+	}
+	// some devices turn on when written to
+	if fb.Content().Enabled != s.Enabled {
+		return lcd.Enable(s.Enabled)
+	}
+	return nil
+}
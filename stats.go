@@ -0,0 +1,113 @@
+package display
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+type (
+	// Stats are the counters of a driver since its construction, log
+	// them periodically for a health summary.
+	Stats struct {
+		// Writes are the frames sent to the display.
+		Writes uint64
+		// Acks are the replies of the display to requests, like the
+		// acknowledgements of ASUSTOR frames or the QNAP handshake.
+		Acks uint64
+		// Retries of unacknowledged frames.
+		Retries uint64
+		// Timeouts of replies and writes.
+		Timeouts uint64
+		// Reconnects are the successful Open calls after the first one.
+		Reconnects uint64
+		// FramesDropped because a queue was full.
+		FramesDropped uint64
+		// UnexpectedReplies of the display nobody waited for, like
+		// acknowledgements arriving after the read timeout.
+		UnexpectedReplies uint64
+	}
+	// StatsReporter is implemented by drivers collecting Stats.
+	StatsReporter interface {
+		Stats() Stats
+	}
+	// counters are updated atomically by the drivers.
+	counters struct {
+		writes            uint64
+		acks              uint64
+		retries           uint64
+		timeouts          uint64
+		opens             uint64
+		framesDropped     uint64
+		unexpectedReplies uint64
+	}
+)
+
+// StatsOf lcd or any display it wraps, ErrNotSupported if none
+// collects them.
+func StatsOf(lcd LCD) (Stats, error) {
+	for _, l := range chain(lcd) {
+		if r, ok := l.(StatsReporter); ok {
+			return r.Stats(), nil
+		}
+	}
+	return Stats{}, ErrNotSupported
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("writes %d, acks %d, retries %d, timeouts %d, reconnects %d, dropped %d, unexpected %d",
+		s.Writes, s.Acks, s.Retries, s.Timeouts, s.Reconnects, s.FramesDropped, s.UnexpectedReplies)
+}
+
+func (c *counters) stats() Stats {
+	s := Stats{
+		Writes:            atomic.LoadUint64(&c.writes),
+		Acks:              atomic.LoadUint64(&c.acks),
+		Retries:           atomic.LoadUint64(&c.retries),
+		Timeouts:          atomic.LoadUint64(&c.timeouts),
+		FramesDropped:     atomic.LoadUint64(&c.framesDropped),
+		UnexpectedReplies: atomic.LoadUint64(&c.unexpectedReplies),
+	}
+	if opens := atomic.LoadUint64(&c.opens); opens > 1 {
+		s.Reconnects = opens - 1
+	}
+	return s
+}
+
+// dropped counts a frame if it wasn't delivered.
+func (c *counters) dropped(delivered bool) {
+	if !delivered {
+		atomic.AddUint64(&c.framesDropped, 1)
+	}
+}
+
+// unexpected counts a reply nobody waited for.
+func (c *counters) unexpected() {
+	atomic.AddUint64(&c.unexpectedReplies, 1)
+}
+
+// wrote counts a frame sent, or a timeout if err is ErrWriteTimeout.
+func (c *counters) wrote(err error) {
+	switch err {
+	case nil:
+		atomic.AddUint64(&c.writes, 1)
+	case ErrWriteTimeout:
+		atomic.AddUint64(&c.timeouts, 1)
+	}
+}
+
+// replied counts a reply or, if none arrived in time, a timeout.
+func (c *counters) replied(ok bool) {
+	if ok {
+		atomic.AddUint64(&c.acks, 1)
+	} else {
+		atomic.AddUint64(&c.timeouts, 1)
+	}
+}
+
+func (c *counters) retried() {
+	atomic.AddUint64(&c.retries, 1)
+}
+
+func (c *counters) opened() {
+	atomic.AddUint64(&c.opens, 1)
+}
@@ -0,0 +1,59 @@
+package display
+
+type (
+	// Clearer is implemented by displays blanking all lines with a
+	// single command.
+	Clearer interface {
+		Clear() error
+	}
+	// StatusReporter is implemented by displays which can be asked if
+	// they respond.
+	StatusReporter interface {
+		Status() (DisplayStatus, error)
+	}
+	// DisplayStatus of a display.
+	DisplayStatus struct {
+		// On is the state set last, none of the devices reports it.
+		On bool
+		// Responsive is true if the display answered.
+		Responsive bool
+	}
+)
+
+// Clear all lines of lcd. Displays without a clear command get empty
+// lines written.
+func Clear(lcd LCD) error {
+	if c, ok := lcd.(Clearer); ok {
+		return c.Clear()
+	}
+	lines := 2
+	if fb, ok := FramebufferOf(lcd); ok {
+		lines = len(fb.Lines())
+	}
+	for i := 0; i < lines; i++ {
+		if err := lcd.Write(Line(i), ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status of lcd or any display it wraps. Displays which can't be asked
+// are responsive if their ConnState is ready, they return
+// ErrNotSupported if they don't track it either.
+func Status(lcd LCD) (DisplayStatus, error) {
+	for _, l := range chain(lcd) {
+		if r, ok := l.(StatusReporter); ok {
+			return r.Status()
+		}
+	}
+	state, ok := ConnStateOf(lcd)
+	if !ok {
+		return DisplayStatus{}, ErrNotSupported
+	}
+	st := DisplayStatus{On: true, Responsive: state == StateReady}
+	if c, err := ContentOf(lcd); err == nil {
+		st.On = c.Enabled
+	}
+	return st, nil
+}
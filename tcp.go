@@ -0,0 +1,174 @@
+package display
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+)
+
+// TCPOpener connects to a serial port of another machine exposed
+// by ser2net or a similar server. Use it with WithSerialOpener:
+//
+//	NewQnapLCD("nas.local:2001", WithSerialOpener(TCPOpener{RFC2217: true}))
+//
+// In raw mode the bytes are passed through as they are and the line
+// settings have to be configured on the server. With RFC2217 the
+// settings of the driver are negotiated over telnet.
+type TCPOpener struct {
+	// Addr of the remote port, the PortName is used if empty.
+	Addr string
+	// RFC2217 enables the telnet com port control protocol.
+	RFC2217 bool
+	// DialTimeout defaults to 5 seconds.
+	DialTimeout time.Duration
+}
+
+// telnet and RFC2217 constants
+const (
+	telnetSE   byte = 240
+	telnetSB   byte = 250
+	telnetWILL byte = 251
+	telnetWONT byte = 252
+	telnetDO   byte = 253
+	telnetDONT byte = 254
+	telnetIAC  byte = 255
+
+	telnetOptBinary   byte = 0
+	telnetOptSGA      byte = 3
+	telnetOptComPort  byte = 44
+	comPortBaudRate   byte = 1
+	comPortDataSize   byte = 2
+	comPortParity     byte = 3
+	comPortStopSize   byte = 4
+	comPortControl    byte = 5
+	comPortParityNone byte = 1
+	comPortFlowNone   byte = 1
+	comPortFlowRTSCTS byte = 3
+)
+
+func (t TCPOpener) OpenSerial(c SerialConfig) (io.ReadWriteCloser, error) {
+	addr := t.Addr
+	if addr == "" {
+		addr = c.PortName
+	}
+	con, err := net.DialTimeout("tcp", addr, durationOr(t.DialTimeout, 5*time.Second))
+	if err != nil {
+		return nil, err
+	}
+	if !t.RFC2217 {
+		return con, nil
+	}
+	tc := &telnetConn{Conn: con}
+	if err = tc.negotiate(c); err != nil {
+		_ = con.Close()
+		return nil, err
+	}
+	return tc, nil
+}
+
+// telnetConn escapes the data and strips the telnet commands
+// the server sends in between.
+type telnetConn struct {
+	net.Conn
+	// the state of the read parser
+	// 0 data, 1 after IAC, 2 option, 3 sub negotiation, 4 IAC in sub negotiation
+	state int
+}
+
+func (t *telnetConn) negotiate(c SerialConfig) error {
+	baud := make([]byte, 4)
+	binary.BigEndian.PutUint32(baud, uint32(c.BaudRate))
+	msg := []byte{
+		telnetIAC, telnetWILL, telnetOptBinary,
+		telnetIAC, telnetDO, telnetOptBinary,
+		telnetIAC, telnetWILL, telnetOptSGA,
+		telnetIAC, telnetDO, telnetOptSGA,
+		telnetIAC, telnetWILL, telnetOptComPort,
+	}
+	msg = append(msg, subNegotiation(comPortBaudRate, baud...)...)
+	msg = append(msg, subNegotiation(comPortDataSize, byte(c.DataBits))...)
+	// the parities count from none
+	msg = append(msg, subNegotiation(comPortParity, comPortParityNone+byte(c.Parity))...)
+	msg = append(msg, subNegotiation(comPortStopSize, byte(c.StopBits))...)
+	flow := comPortFlowNone
+	if c.RTSCTSFlowControl {
+		flow = comPortFlowRTSCTS
+	}
+	msg = append(msg, subNegotiation(comPortControl, flow)...)
+	_, err := t.Conn.Write(msg)
+	return err
+}
+
+func subNegotiation(cmd byte, data ...byte) []byte {
+	return append(append([]byte{telnetIAC, telnetSB, telnetOptComPort, cmd}, escapeIAC(data)...), telnetIAC, telnetSE)
+}
+
+func escapeIAC(data []byte) []byte {
+	res := make([]byte, 0, len(data))
+	for _, b := range data {
+		if b == telnetIAC {
+			res = append(res, telnetIAC)
+		}
+		res = append(res, b)
+	}
+	return res
+}
+
+func (t *telnetConn) Write(p []byte) (int, error) {
+	_, err := t.Conn.Write(escapeIAC(p))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *telnetConn) Read(p []byte) (int, error) {
+	for {
+		n, err := t.Conn.Read(p)
+		if n == 0 {
+			return 0, err
+		}
+		i := 0
+		for _, b := range p[:n] {
+			switch t.state {
+			case 0:
+				if b == telnetIAC {
+					t.state = 1
+					continue
+				}
+				p[i] = b
+				i++
+			case 1:
+				switch b {
+				case telnetIAC:
+					p[i] = b
+					i++
+					t.state = 0
+				case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+					t.state = 2
+				case telnetSB:
+					t.state = 3
+				default:
+					t.state = 0
+				}
+			case 2:
+				t.state = 0
+			case 3:
+				if b == telnetIAC {
+					t.state = 4
+				}
+			case 4:
+				if b == telnetSE {
+					t.state = 0
+				} else {
+					t.state = 3
+				}
+			}
+		}
+		// don't report an empty read if there were only commands
+		if i > 0 || err != nil {
+			return i, err
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Package text lays out the lines of character displays. It has no
+// dependencies besides strings and unicode/utf8 and compiles with
+// TinyGo, so projects on microcontrollers can use it with their own
+// transport, like the frame encoders of asustorproto and qnapproto.
+package text
+
+import "strings"
+
+// Filled is the full block of the panels, used by Progress.
+const Filled = "\xff"
+
+// Fit cuts s to width bytes or pads it with spaces. The panels have
+// single byte charsets, so s isn't treated as UTF-8.
+func Fit(s string, width int) string {
+	return FitPad(s, width, ' ')
+}
+
+// FitPad works like Fit but pads with pad.
+func FitPad(s string, width int, pad byte) string {
+	if len(s) > width {
+		return s[:width]
+	}
+	return s + strings.Repeat(string(pad), width-len(s))
+}
+
+// Progress is a bar of width characters filled by percent,
+// which is clamped to 0 up to 100.
+func Progress(percent, width int) string {
+	return ProgressWith(percent, width, Filled, "-")
+}
+
+// ProgressWith works like Progress with the characters of the filled
+// and the empty part of the bar.
+func ProgressWith(percent, width int, filled, empty string) string {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	chars := percentOf(width, 100, percent)
+	return strings.Repeat(filled, chars) + strings.Repeat(empty, width-chars)
+}
+
+// percentOf width filled by percent of maxPercent.
+func percentOf(width, maxPercent, percent int) int {
+	return (width * percent) / maxPercent
+}
+
+// Escape introduces the control codes of Expand.
+const Escape = "\x1b["
+
+// Expand fits s to width like FitPad and applies the control codes in
+// it, so formatting can travel as plain string through config files
+// or the HTTP interface:
+//
+//	\x1b[L   the following text is aligned left, the default
+//	\x1b[C   the following text is centered
+//	\x1b[R   the following text is aligned right
+//	\x1b[K   clears the rest of the line with spaces instead of pad,
+//	         the text after it is dropped
+//	\x1b[Ng  the character N of the ROM in decimal, like 0 to 7 for
+//	         the custom glyphs
+//
+// Unknown codes are kept as they are. Text aligned left wins over
+// right aligned and centered text if they overlap.
+func Expand(s string, width int, pad byte) string {
+	if !strings.Contains(s, Escape) {
+		return FitPad(s, width, pad)
+	}
+	// left, centered and right aligned text
+	var parts [3]strings.Builder
+	cur := 0
+	for s != "" {
+		i := strings.Index(s, Escape)
+		if i < 0 {
+			parts[cur].WriteString(s)
+			break
+		}
+		parts[cur].WriteString(s[:i])
+		s = s[i+len(Escape):]
+		n, digits := 0, 0
+		for digits < len(s) && digits < 3 && s[digits] >= '0' && s[digits] <= '9' {
+			n = n*10 + int(s[digits]-'0')
+			digits++
+		}
+		if digits == len(s) {
+			parts[cur].WriteString(Escape + s)
+			break
+		}
+		switch code := s[digits]; {
+		case digits == 0 && code == 'L':
+			cur = 0
+		case digits == 0 && code == 'C':
+			cur = 1
+		case digits == 0 && code == 'R':
+			cur = 2
+		case digits == 0 && code == 'K':
+			pad, s = ' ', ""
+			continue
+		case digits > 0 && code == 'g' && n < 256:
+			parts[cur].WriteByte(byte(n))
+		default:
+			parts[cur].WriteString(Escape)
+			continue
+		}
+		s = s[digits+1:]
+	}
+	line := []byte(strings.Repeat(string(pad), width))
+	if c := parts[1].String(); c != "" {
+		copy(line[clamp((width-len(c))/2, 0, width):], c)
+	}
+	if r := parts[2].String(); r != "" {
+		copy(line[clamp(width-len(r), 0, width):], r)
+	}
+	copy(line, parts[0].String())
+	return string(line)
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
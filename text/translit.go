@@ -0,0 +1,60 @@
+package text
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Unknown replaces the characters Transliterate has no mapping for.
+const Unknown = "?"
+
+// translit maps characters outside of ASCII to what the panels show.
+var translit = map[rune]string{
+	'ä': "ae", 'ö': "oe", 'ü': "ue", 'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue", 'ß': "ss",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'å': "a", 'æ': "ae",
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Å': "A", 'Æ': "Ae",
+	'ç': "c", 'Ç': "C", 'ñ': "n", 'Ñ': "N",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ø': "o", 'œ': "oe",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ø': "O", 'Œ': "Oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'Ù': "U", 'Ú': "U", 'Û': "U",
+	'ý': "y", 'ÿ': "y", 'Ý': "Y",
+	// the degree sign of the HD44780 ROM
+	'°': "\xdf",
+	'€': "EUR", '…': "...", '–': "-", '—': "-",
+	'‘': "'", '’': "'", '“': "\"", '”': "\"", '«': "<<", '»': ">>",
+	'\u00a0': " ",
+}
+
+// Transliterate UTF-8 text to the single byte charset of the panels.
+// Bytes which aren't valid UTF-8, like Filled, are kept as they are, so
+// text already mapped to the character ROM passes through.
+func Transliterate(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+	var b strings.Builder
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		switch {
+		case r < utf8.RuneSelf, r == utf8.RuneError && size == 1:
+			b.WriteByte(s[0])
+		default:
+			if t, ok := translit[r]; ok {
+				b.WriteString(t)
+			} else {
+				b.WriteString(Unknown)
+			}
+		}
+		s = s[size:]
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package display
+
+import (
+	"fmt"
+	"github.com/artvel/display/text"
+	"sync"
+)
+
+// Theme is the look of the helpers of the package, like the cursor
+// of the ListPicker or the bar of ProgressOn. Empty fields fall back
+// to the default theme. All characters are single bytes of the
+// character ROM of the panels.
+type Theme struct {
+	// Pad fills the lines up to the width of the display.
+	Pad byte
+	// Filled and Empty draw the parts of a progress bar.
+	Filled string
+	Empty  string
+	// Cursor marks the selection of a list or a dialog.
+	Cursor string
+	// More and Less are shown if a list continues below or above.
+	More string
+	Less string
+}
+
+var (
+	themeM sync.RWMutex
+	theme  = "default"
+	themes = map[string]Theme{
+		"default": {Pad: ' ', Filled: text.Filled, Empty: "-", Cursor: ">", More: "v", Less: "^"},
+		// ascii looks the same on panels with another character ROM
+		"ascii": {Filled: "#", Empty: "."},
+		// arrows of the HD44780 ROM
+		"arrows": {Cursor: "\x7e", Empty: " "},
+	}
+)
+
+// SetTheme switches the helpers to the theme name. It fails for
+// unknown themes, add them with RegisterTheme.
+func SetTheme(name string) error {
+	themeM.Lock()
+	defer themeM.Unlock()
+
+	if _, ok := themes[name]; !ok {
+		return fmt.Errorf("unknown theme %q", name)
+	}
+	theme = name
+	return nil
+}
+
+// RegisterTheme adds or replaces the theme name, for example with the
+// look of an appliance vendor.
+func RegisterTheme(name string, t Theme) {
+	themeM.Lock()
+	defer themeM.Unlock()
+
+	themes[name] = t
+}
+
+// CurrentTheme with the defaults filled in.
+func CurrentTheme() Theme {
+	themeM.RLock()
+	t, def := themes[theme], themes["default"]
+	themeM.RUnlock()
+
+	if t.Pad == 0 {
+		t.Pad = def.Pad
+	}
+	t.Filled = stringOr(t.Filled, def.Filled)
+	t.Empty = stringOr(t.Empty, def.Empty)
+	t.Cursor = stringOr(t.Cursor, def.Cursor)
+	t.More = stringOr(t.More, def.More)
+	t.Less = stringOr(t.Less, def.Less)
+	return t
+}
@@ -0,0 +1,78 @@
+package display
+
+import "context"
+
+type (
+	// Tracer starts spans around Open, Write, Enable and the serial
+	// round trips of the drivers. It is modeled after OpenTelemetry
+	// without depending on it, adapt your tracer in a few lines:
+	//
+	//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, display.Span) {
+	//		ctx, s := t.tracer.Start(ctx, name)
+	//		return ctx, otelSpan{s}
+	//	}
+	Tracer interface {
+		Start(ctx context.Context, name string) (context.Context, Span)
+	}
+	// Span is a single traced operation.
+	Span interface {
+		SetAttribute(key string, value interface{})
+		RecordError(err error)
+		End()
+	}
+
+	noopTracer struct{}
+	noopSpan   struct{}
+
+	// tracer of a driver, the context links round trips to their operation.
+	tracer struct {
+		Tracer
+		driver string
+		ctx    context.Context
+	}
+)
+
+// WithTracer traces the operations of a driver.
+func WithTracer(t Tracer) Option {
+	return func(o *options) {
+		o.tracer = t
+	}
+}
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) RecordError(err error)                      {}
+func (noopSpan) End()                                       {}
+
+func newTracer(t Tracer, driver string) *tracer {
+	if t == nil {
+		t = noopTracer{}
+	}
+	return &tracer{Tracer: t, driver: driver, ctx: context.Background()}
+}
+
+// start a span as child of the current operation. Call the returned
+// function with the result to end it. Attributes are key value pairs.
+// The drivers trace under their lock, so keeping the current context
+// in the tracer is safe.
+func (t *tracer) start(name string, attrs ...interface{}) func(err error) {
+	parent := t.ctx
+	ctx, span := t.Tracer.Start(parent, name)
+	t.ctx = ctx
+	span.SetAttribute("display.driver", t.driver)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if key, ok := attrs[i].(string); ok {
+			span.SetAttribute(key, attrs[i+1])
+		}
+	}
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+		t.ctx = parent
+	}
+}
@@ -0,0 +1,139 @@
+package display
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// connector opens the connection to the display.
+type connector func() (io.ReadWriteCloser, error)
+
+// connOnce hands out a connection which was passed in by the user.
+// Once the display closed it, it can't be reopened.
+func connOnce(rwc io.ReadWriteCloser) connector {
+	used := false
+	return func() (io.ReadWriteCloser, error) {
+		if used {
+			return nil, ErrClosed
+		}
+		used = true
+		return rwc, nil
+	}
+}
+
+type (
+	// conn runs the writes of a connection on one goroutine, so writes
+	// which time out don't leave a goroutine per frame behind. Where
+	// the connection supports it, a deadline makes the stuck write give
+	// up by itself, otherwise it blocks until the connection is closed.
+	conn struct {
+		rwc    io.ReadWriteCloser
+		writes chan connWrite
+		closed chan struct{}
+		once   sync.Once
+	}
+	connWrite struct {
+		b       []byte
+		timeout time.Duration
+		done    chan connResult
+	}
+	connResult struct {
+		n   int
+		err error
+	}
+	writeDeadliner interface {
+		SetWriteDeadline(t time.Time) error
+	}
+)
+
+// dial connects and starts the writer, bg tracks it until the
+// connection is closed.
+func dial(connect connector, bg *sync.WaitGroup) (*conn, error) {
+	rwc, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{rwc: rwc, writes: make(chan connWrite), closed: make(chan struct{})}
+	bg.Add(1)
+	go c.run(bg)
+	return c, nil
+}
+
+func (c *conn) run(bg *sync.WaitGroup) {
+	defer bg.Done()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case w := <-c.writes:
+			if d, ok := c.rwc.(writeDeadliner); ok {
+				// the port knows only the real time
+				_ = d.SetWriteDeadline(time.Now().Add(w.timeout))
+			}
+			var r connResult
+			r.n, r.err = c.rwc.Write(w.b)
+			if errors.Is(r.err, os.ErrDeadlineExceeded) {
+				r.err = ErrWriteTimeout
+			}
+			w.done <- r
+		}
+	}
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	return c.rwc.Read(b)
+}
+
+// Close the connection, it stops the writer once a pending write
+// returned.
+func (c *conn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return c.rwc.Close()
+}
+
+// writeTimeout writes b to con and gives up after d with
+// ErrWriteTimeout, also if a former write is still stuck.
+func writeTimeout(c Clock, con *conn, b []byte, d time.Duration) (int, error) {
+	w := connWrite{b: b, timeout: d, done: make(chan connResult, 1)}
+	deadline := c.After(d)
+	select {
+	case con.writes <- w:
+	case <-con.closed:
+		return 0, ErrClosed
+	case <-deadline:
+		return 0, ErrWriteTimeout
+	}
+	select {
+	case r := <-w.done:
+		return r.n, r.err
+	case <-deadline:
+		return 0, ErrWriteTimeout
+	}
+}
+
+// await blocks until all chans are closed and reports false if d
+// passed before.
+func await(c Clock, d time.Duration, chans ...<-chan struct{}) bool {
+	deadline := c.After(d)
+	for _, ch := range chans {
+		select {
+		case <-ch:
+		case <-deadline:
+			return false
+		}
+	}
+	return true
+}
+
+// waitDone is closed once wg is done.
+func waitDone(wg *sync.WaitGroup) <-chan struct{} {
+	c := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(c)
+	}()
+	return c
+}
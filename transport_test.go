@@ -0,0 +1,84 @@
+package display
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stuckConn blocks all reads and writes until it is closed.
+type stuckConn struct {
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (s *stuckConn) Read(b []byte) (int, error) {
+	<-s.closed
+	return 0, io.EOF
+}
+
+func (s *stuckConn) Write(b []byte) (int, error) {
+	<-s.closed
+	return 0, io.ErrClosedPipe
+}
+
+func (s *stuckConn) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+func TestWriteTimeoutUsesOneWriter(t *testing.T) {
+	defer noLeaks(t)()
+	c := newFakeClock()
+	bg := &sync.WaitGroup{}
+	con, err := dial(connOnce(&stuckConn{closed: make(chan struct{})}), bg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		res := make(chan error, 1)
+		go func() {
+			_, err := writeTimeout(c, con, []byte("x"), time.Second)
+			res <- err
+		}()
+		c.waiting(t, 1)
+		c.Advance(time.Second)
+		if err = <-res; err != ErrWriteTimeout {
+			t.Fatalf("write %d: got %v, want ErrWriteTimeout", i+1, err)
+		}
+	}
+	_ = con.Close()
+	if !await(realClock{}, time.Second, waitDone(bg)) {
+		t.Fatal("the writer didn't stop after Close")
+	}
+	if _, err = writeTimeout(c, con, []byte("x"), time.Second); err != ErrClosed {
+		t.Errorf("got %v writing after Close, want ErrClosed", err)
+	}
+}
+
+func TestWriteTimeoutSetsTheDeadline(t *testing.T) {
+	defer noLeaks(t)()
+	local, remote := net.Pipe()
+	defer remote.Close()
+	bg := &sync.WaitGroup{}
+	con, err := dial(connOnce(local), bg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = con.Close()
+		bg.Wait()
+	}()
+	// the fake clock doesn't move, only the deadline of the port
+	// ends the write nobody reads
+	c := newFakeClock()
+	if _, err = writeTimeout(c, con, []byte("x"), 20*time.Millisecond); err != ErrWriteTimeout {
+		t.Fatalf("got %v, want ErrWriteTimeout", err)
+	}
+	go func() { _, _ = io.ReadFull(remote, make([]byte, 2)) }()
+	if n, err := writeTimeout(c, con, []byte("ok"), time.Second); err != nil || n != 2 {
+		t.Errorf("got %d, %v writing after a timeout", n, err)
+	}
+}
@@ -0,0 +1,74 @@
+package display
+
+import "sort"
+
+type (
+	// Tx stages lines to show them together, like a label and its
+	// value. Create it with Begin.
+	Tx struct {
+		lcd   LCD
+		lines map[Line]string
+	}
+	lineText struct {
+		line Line
+		text string
+	}
+	// batchWriter is implemented by the drivers writing the lines of a
+	// transaction in one paced burst under their lock.
+	batchWriter interface {
+		writeLines(lines []lineText) error
+	}
+)
+
+// Begin a transaction on lcd. Nothing is written until Commit.
+func Begin(lcd LCD) *Tx {
+	return &Tx{lcd: lcd, lines: map[Line]string{}}
+}
+
+// Set stages text for line, a later Set of the same line wins.
+func (t *Tx) Set(line Line, text string) {
+	t.lines[line] = text
+}
+
+// Commit writes the staged lines in order. If a write fails, the lines
+// written before are set back to their previous text if the display
+// keeps track of it, and the error is returned. The transaction is
+// empty afterwards and can be reused.
+func (t *Tx) Commit() error {
+	lines := make([]lineText, 0, len(t.lines))
+	for line, text := range t.lines {
+		lines = append(lines, lineText{line, text})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].line < lines[j].line })
+	t.Rollback()
+	if b, ok := t.lcd.(batchWriter); ok {
+		return b.writeLines(lines)
+	}
+	fb, _ := FramebufferOf(t.lcd)
+	return commitLines(fb, t.lcd.Write, lines)
+}
+
+// Rollback drops the staged lines.
+func (t *Tx) Rollback() {
+	t.lines = map[Line]string{}
+}
+
+// commitLines with write and restore the previous content of fb if
+// one fails. Without a framebuffer nothing is restored.
+func commitLines(fb *Framebuffer, write func(line Line, text string) error, lines []lineText) error {
+	var prev []string
+	if fb != nil {
+		prev = fb.Lines()
+	}
+	for i, l := range lines {
+		if err := write(l.line, l.text); err != nil {
+			for _, done := range lines[:i] {
+				if int(done.line) < len(prev) {
+					_ = write(done.line, prev[done.line])
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package display
+
+import (
+	"context"
+	"sync"
+)
+
+// VirtualScreen is a display with more lines than the panel, like a
+// list of disks or containers, of which the panel shows a viewport.
+// Write to its lines like to any display, Navigate scrolls with the up
+// and down keys.
+type VirtualScreen struct {
+	decorator
+	m  sync.Mutex
+	fb *Framebuffer
+	// top is the first line shown, rows the lines of the panel
+	top  int
+	rows int
+}
+
+// NewVirtualScreen of lines lines on lcd.
+func NewVirtualScreen(lcd LCD, lines int) *VirtualScreen {
+	rows := 2
+	if fb, ok := FramebufferOf(lcd); ok {
+		rows = len(fb.Lines())
+	}
+	return &VirtualScreen{decorator: decorator{lcd}, fb: NewFramebuffer(lines), rows: rows}
+}
+
+// Write text on a line of the virtual screen, it is shown right away
+// if it is in the viewport.
+func (v *VirtualScreen) Write(line Line, text string) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if _, err := v.fb.Line(line); err != nil {
+		return err
+	}
+	v.fb.Set(line, text)
+	if row := int(line) - v.top; row >= 0 && row < v.rows {
+		return v.LCD.Write(Line(row), text)
+	}
+	return nil
+}
+
+func (v *VirtualScreen) Enable(yes bool) error {
+	err := v.LCD.Enable(yes)
+	if err == nil {
+		v.fb.SetEnabled(yes)
+	}
+	return err
+}
+
+// Framebuffer of the virtual lines.
+func (v *VirtualScreen) Framebuffer() *Framebuffer {
+	return v.fb
+}
+
+// Top is the first line in the viewport.
+func (v *VirtualScreen) Top() Line {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	return Line(v.top)
+}
+
+// Scroll the viewport by delta lines, negative ones scroll up. It
+// stops at the first and last line.
+func (v *VirtualScreen) Scroll(delta int) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	return v.scrollTo(v.top + delta)
+}
+
+// ScrollTo shows line at the top, or as close as possible.
+func (v *VirtualScreen) ScrollTo(line Line) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	return v.scrollTo(int(line))
+}
+
+// Redraw the viewport, for example after somebody else wrote to the
+// display.
+func (v *VirtualScreen) Redraw() error {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	return v.draw()
+}
+
+// Navigate scrolls the viewport with the up and down keys of km until
+// ctx is done, a nil keymap means DefaultKeymap.
+func (v *VirtualScreen) Navigate(ctx context.Context, km Keymap) {
+	ListenKeysContext(ctx, v.LCD, km, func(key Key, ev ButtonEvent) bool {
+		switch key {
+		case KeyUp:
+			_ = v.Scroll(-1)
+		case KeyDown:
+			_ = v.Scroll(1)
+		}
+		return true
+	})
+}
+
+// scrollTo must be called with the lock held.
+func (v *VirtualScreen) scrollTo(top int) error {
+	if last := len(v.fb.Lines()) - v.rows; top > last {
+		top = last
+	}
+	if top < 0 {
+		top = 0
+	}
+	if top == v.top {
+		return nil
+	}
+	v.top = top
+	return v.draw()
+}
+
+// draw the viewport, must be called with the lock held.
+func (v *VirtualScreen) draw() error {
+	lines := v.fb.Lines()
+	for row := 0; row < v.rows; row++ {
+		txt := ""
+		if i := v.top + row; i < len(lines) {
+			txt = lines[i]
+		}
+		if err := v.LCD.Write(Line(row), txt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
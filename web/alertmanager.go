@@ -0,0 +1,71 @@
+package web
+
+import (
+	"encoding/json"
+	"github.com/artvel/display/pages"
+	"net/http"
+	"strings"
+)
+
+type (
+	// alertmanagerWebhook is the payload of the Alertmanager webhook
+	// receiver, reduced to what we show.
+	alertmanagerWebhook struct {
+		Alerts []alertmanagerAlert `json:"alerts"`
+	}
+	alertmanagerAlert struct {
+		Status      string            `json:"status"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+		Fingerprint string            `json:"fingerprint"`
+	}
+)
+
+// NewAlertmanagerHandler accepts Prometheus Alertmanager webhooks and
+// shows the firing alerts as toasts on b until they are resolved.
+// Alerts labeled severity=critical preempt all other toasts.
+func NewAlertmanagerHandler(b *pages.Board) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var hook alertmanagerWebhook
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&hook); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, a := range hook.Alerts {
+			id := "alertmanager/" + a.id()
+			if a.Status == "resolved" {
+				b.Dismiss(id)
+				continue
+			}
+			b.Show(pages.Toast{ID: id, Lines: a.lines(), Priority: a.priority()})
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (a alertmanagerAlert) id() string {
+	if a.Fingerprint != "" {
+		return a.Fingerprint
+	}
+	return a.Labels["alertname"] + "/" + a.Labels["instance"]
+}
+
+func (a alertmanagerAlert) priority() pages.Priority {
+	if strings.EqualFold(a.Labels["severity"], "critical") {
+		return pages.PriorityCritical
+	}
+	return pages.PriorityHigh
+}
+
+// lines show the alert name and the summary or the instance.
+func (a alertmanagerAlert) lines() []string {
+	second := a.Annotations["summary"]
+	if second == "" {
+		second = a.Labels["instance"]
+	}
+	return []string{"!" + a.Labels["alertname"], second}
+}
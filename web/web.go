@@ -0,0 +1,241 @@
+/*
+Package web serves a display over HTTP. It reports the content
+and streams a live mirror of the panel over a WebSocket, so a
+support engineer can see exactly what the panel shows.
+
+	GET  /content       the content as JSON
+	GET  /live          WebSocket stream of content and button messages
+	GET  /snapshot.png  the content rendered as an image
+
+With WithControl it also accepts writes and simulated button presses,
+which can trigger whatever the buttons do, up to a power off:
+
+	POST /lines/N       write the request body on line N
+	POST /buttons       inject {"button": 1, "released": true}
+*/
+package web
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"github.com/artvel/display"
+	"github.com/artvel/display/render"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Message is sent on the live stream.
+	Message struct {
+		// Type is "content" or "button".
+		Type    string   `json:"type"`
+		Lines   []string `json:"lines,omitempty"`
+		Enabled bool     `json:"enabled,omitempty"`
+		Button  *Button  `json:"button,omitempty"`
+	}
+	// Button describes a button event.
+	Button struct {
+		Name     string        `json:"name"`
+		Raw      int           `json:"raw"`
+		Released bool          `json:"released"`
+		Time     time.Time     `json:"time"`
+		Held     time.Duration `json:"held,omitempty"`
+	}
+
+	// Option configures NewHandler.
+	Option func(*handler)
+
+	handler struct {
+		lcd   display.LCD
+		mux   *http.ServeMux
+		token string
+	}
+	buttonRequest struct {
+		Button   int  `json:"button"`
+		Released bool `json:"released"`
+	}
+)
+
+// live messages are dropped for slow clients beyond this amount
+const liveBuffer = 64
+
+// NewHandler serves lcd read-only unless WithControl is given.
+func NewHandler(lcd display.LCD, opts ...Option) http.Handler {
+	h := &handler{lcd: lcd, mux: http.NewServeMux()}
+	for _, o := range opts {
+		o(h)
+	}
+	h.mux.HandleFunc("/content", h.content)
+	h.mux.HandleFunc("/live", h.live)
+	h.mux.HandleFunc("/snapshot.png", h.snapshot)
+	if h.token != "" {
+		h.mux.HandleFunc("/lines/", h.authorized(h.write))
+		h.mux.HandleFunc("/buttons", h.authorized(h.button))
+	}
+	return h
+}
+
+// WithControl accepts writes and button presses from requests sending
+// the header "Authorization: Bearer token". An empty token keeps
+// the handler read-only.
+func WithControl(token string) Option {
+	return func(h *handler) {
+		h.token = token
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *handler) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *handler) content(w http.ResponseWriter, r *http.Request) {
+	c, err := display.ContentOf(h.lcd)
+	if err != nil {
+		http.Error(w, "display content unknown", http.StatusNotImplemented)
+		return
+	}
+	writeJSON(w, contentMessage(c))
+}
+
+func (h *handler) snapshot(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := render.Snapshot(&buf, h.lcd, render.DefaultStyle); err != nil {
+		http.Error(w, "display content unknown", http.StatusNotImplemented)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (h *handler) write(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	line, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/lines/"))
+	if err != nil {
+		http.Error(w, "invalid line", http.StatusBadRequest)
+		return
+	}
+	text, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1024))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = h.lcd.Write(display.Line(line), string(text)); err != nil {
+		status := http.StatusBadGateway
+		switch {
+		case errors.Is(err, display.ErrUnsupportedLine):
+			status = http.StatusBadRequest
+		case errors.Is(err, display.ErrDisabled):
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) button(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req buttonRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := display.InjectButton(h.lcd, req.Button, req.Released); err != nil {
+		status := http.StatusBadGateway
+		if err == display.ErrNotSupported {
+			status = http.StatusNotImplemented
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) live(w http.ResponseWriter, r *http.Request) {
+	con, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer con.Close()
+
+	msgs := make(chan Message, liveBuffer)
+	send := func(m Message) {
+		select {
+		case msgs <- m:
+		default:
+		}
+	}
+	cancelContent, _ := display.ObserveContent(h.lcd, func(c display.Content) {
+		send(contentMessage(c))
+	})
+	defer cancelContent()
+	cancelButtons, _ := display.ObserveButtons(h.lcd, func(ev display.ButtonEvent) {
+		send(buttonMessage(ev))
+	})
+	defer cancelButtons()
+
+	closed := make(chan struct{})
+	go func() {
+		_ = con.readLoop()
+		close(closed)
+	}()
+	for {
+		select {
+		case m := <-msgs:
+			b, err := json.Marshal(m)
+			if err != nil {
+				continue
+			}
+			if err = con.writeText(b); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func contentMessage(c display.Content) Message {
+	return Message{Type: "content", Lines: c.Lines, Enabled: c.Enabled}
+}
+
+func buttonMessage(ev display.ButtonEvent) Message {
+	return Message{Type: "button", Button: &Button{
+		Name:     ev.Button.String(),
+		Raw:      ev.Raw,
+		Released: ev.Released,
+		Time:     ev.Time,
+		Held:     ev.Held,
+	}}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
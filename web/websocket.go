@@ -0,0 +1,177 @@
+package web
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// The server side of RFC 6455, just enough to push messages
+// to a browser and notice when it goes away.
+
+const (
+	wsGUID        = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsOpText      = 0x1
+	wsOpClose     = 0x8
+	wsOpPing      = 0x9
+	wsOpPong      = 0xa
+	wsMaxReadSize = 1 << 16
+)
+
+var (
+	errNotWebSocket = errors.New("not a websocket handshake")
+	errCrossOrigin  = errors.New("cross origin websocket")
+	errUnmasked     = errors.New("unmasked websocket frame")
+)
+
+type wsConn struct {
+	con net.Conn
+	rw  *bufio.ReadWriter
+	m   sync.Mutex
+}
+
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		http.Error(w, errNotWebSocket.Error(), http.StatusBadRequest)
+		return nil, errNotWebSocket
+	}
+	if !sameOrigin(r) {
+		http.Error(w, errCrossOrigin.Error(), http.StatusForbidden)
+		return nil, errCrossOrigin
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return nil, errNotWebSocket
+	}
+	con, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	_, err = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		_ = con.Close()
+		return nil, err
+	}
+	return &wsConn{con: con, rw: rw}, nil
+}
+
+// sameOrigin protects against other sites opening the stream in the
+// browser of a user. Clients that aren't browsers send no Origin.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func (c *wsConn) writeText(msg []byte) error {
+	return c.writeFrame(wsOpText, msg)
+}
+
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	header := []byte{0x80 | op}
+	switch l := len(payload); {
+	case l < 126:
+		header = append(header, byte(l))
+	case l <= 0xffff:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(l))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(l))
+	}
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// readLoop discards the messages of the client, answers pings and
+// returns once the client closed the connection.
+func (c *wsConn) readLoop() error {
+	for {
+		op, payload, err := c.readFrame()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case wsOpClose:
+			_ = c.writeFrame(wsOpClose, nil)
+			return io.EOF
+		case wsOpPing:
+			if err = c.writeFrame(wsOpPong, payload); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (op byte, payload []byte, err error) {
+	header := make([]byte, 2)
+	if _, err = io.ReadFull(c.rw, header); err != nil {
+		return
+	}
+	op = header[0] & 0x0f
+	// clients must mask all frames
+	if header[1]&0x80 == 0 {
+		return 0, nil, errUnmasked
+	}
+	size := uint64(header[1] & 0x7f)
+	switch size {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err = io.ReadFull(c.rw, ext); err != nil {
+			return
+		}
+		size = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err = io.ReadFull(c.rw, ext); err != nil {
+			return
+		}
+		size = binary.BigEndian.Uint64(ext)
+	}
+	if size > wsMaxReadSize {
+		return 0, nil, errors.New("websocket frame too large")
+	}
+	mask := make([]byte, 4)
+	if _, err = io.ReadFull(c.rw, mask); err != nil {
+		return
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(c.rw, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, payload, nil
+}
+
+func (c *wsConn) Close() error {
+	return c.con.Close()
+}
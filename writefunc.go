@@ -0,0 +1,55 @@
+package display
+
+import (
+	"fmt"
+	"github.com/artvel/display/text"
+	"sync"
+	"time"
+)
+
+// Writef formats like fmt.Sprintf and writes the result on line. The
+// text is transliterated to the charset of the panels, so umlauts and
+// accents don't garble the line, and cut or padded to the width of the
+// display by Write.
+func Writef(lcd LCD, line Line, format string, args ...interface{}) error {
+	return lcd.Write(line, text.Transliterate(fmt.Sprintf(format, args...)))
+}
+
+// WriteFunc writes the result of fn on line right away and then every
+// interval, but only if it changed, for example a clock or a counter.
+// It stops once stop is called, the display is closed or a write fails
+// with ErrClosed. fn runs on a goroutine of its own.
+func WriteFunc(lcd LCD, line Line, interval time.Duration, fn func() string) (stop func()) {
+	stopC := make(chan struct{})
+	var closed <-chan struct{}
+	for _, l := range chain(lcd) {
+		if n, ok := l.(closeNotifier); ok {
+			closed = n.closeNotify()
+			break
+		}
+	}
+	c := ClockOf(lcd)
+	go func() {
+		shown, written := "", false
+		for {
+			if txt := fn(); !written || txt != shown {
+				err := lcd.Write(line, txt)
+				if err == ErrClosed {
+					return
+				}
+				shown, written = txt, err == nil
+			}
+			select {
+			case <-c.After(interval):
+			case <-closed:
+				return
+			case <-stopC:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stopC) })
+	}
+}